@@ -25,6 +25,11 @@ type artifact struct {
 	dir string
 	f   []string
 
+	// The name of the VM if it was left registered, and the driver used to
+	// unregister it when the artifact is destroyed
+	vmName string
+	driver Driver
+
 	// StateData should store data such as GeneratedData
 	// to be shared with post-processors
 	StateData map[string]interface{}
@@ -70,6 +75,20 @@ func NewArtifact(dir string, generatedData map[string]interface{}) (packersdk.Ar
 	}, nil
 }
 
+// NewRegisteredArtifact returns a Parallels artifact containing the files in
+// the given directory, which belong to a VM that is still registered in
+// Parallels Desktop under the given name.
+func NewRegisteredArtifact(dir string, vmName string, driver Driver, generatedData map[string]interface{}) (packersdk.Artifact, error) {
+	a, err := NewArtifact(dir, generatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	a.(*artifact).vmName = vmName
+	a.(*artifact).driver = driver
+	return a, nil
+}
+
 func (*artifact) BuilderId() string {
 	return BuilderId
 }
@@ -78,11 +97,17 @@ func (a *artifact) Files() []string {
 	return a.f
 }
 
-func (*artifact) Id() string {
+func (a *artifact) Id() string {
+	if a.vmName != "" {
+		return a.vmName
+	}
 	return "VM"
 }
 
 func (a *artifact) String() string {
+	if a.vmName != "" {
+		return fmt.Sprintf("VM '%s' registered with files in directory: %s", a.vmName, a.dir)
+	}
 	return fmt.Sprintf("VM files in directory: %s", a.dir)
 }
 
@@ -91,5 +116,10 @@ func (a *artifact) State(name string) interface{} {
 }
 
 func (a *artifact) Destroy() error {
+	if a.vmName != "" {
+		if err := a.driver.Prlctl("unregister", a.vmName); err != nil {
+			return err
+		}
+	}
 	return os.RemoveAll(a.dir)
 }
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -48,3 +49,32 @@ func TestNewArtifact(t *testing.T) {
 		t.Fatalf("bad: should length have generated_data: %s", a.State("generated_data"))
 	}
 }
+
+func TestNewRegisteredArtifact(t *testing.T) {
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+
+	driver := new(DriverMock)
+	a, err := NewRegisteredArtifact(td, "foo", driver, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if a.Id() != "foo" {
+		t.Fatalf("bad: %#v", a.Id())
+	}
+
+	if err := a.Destroy(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := [][]string{{"unregister", "foo"}}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+	if _, err := os.Stat(td); !os.IsNotExist(err) {
+		t.Fatal("directory should be removed")
+	}
+}
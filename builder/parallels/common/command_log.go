@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// commandLogEntry is a single line of the prlctl command log.
+type commandLogEntry struct {
+	Time     string   `json:"time"`
+	Args     []string `json:"args"`
+	ExitCode int      `json:"exit_code"`
+}
+
+// commandLog records executed prlctl commands to a file as JSON lines.
+type commandLog struct {
+	sync.Mutex
+	file *os.File
+}
+
+// openCommandLog opens the command log at the given path for appending,
+// creating it if it doesn't exist.
+func openCommandLog(path string) (*commandLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &commandLog{file: f}, nil
+}
+
+// Record appends a command to the log and flushes it to disk. Sensitive
+// values are filtered out of the arguments.
+func (l *commandLog) Record(args []string, exitCode int) error {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	filtered := make([]string, len(args))
+	for i, arg := range args {
+		filtered[i] = packersdk.LogSecretFilter.FilterString(arg)
+	}
+
+	line, err := json.Marshal(commandLogEntry{
+		Time:     time.Now().UTC().Format(time.RFC3339),
+		Args:     filtered,
+		ExitCode: exitCode,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return l.file.Sync()
+}
+
+// Close closes the log file. Commands recorded after the log is closed are
+// discarded.
+func (l *commandLog) Close() error {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
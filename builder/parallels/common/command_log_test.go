@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandLog_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prlctl.log")
+
+	l, err := openCommandLog(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer l.file.Close()
+
+	if err := l.Record([]string{"list", "-a"}, 0); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := l.Record([]string{"start", "foo"}, 255); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("bad: %#v", lines)
+	}
+
+	var entry commandLogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(entry.Args, []string{"start", "foo"}) {
+		t.Fatalf("bad: %#v", entry.Args)
+	}
+	if entry.ExitCode != 255 {
+		t.Fatalf("bad: %d", entry.ExitCode)
+	}
+	if entry.Time == "" {
+		t.Fatal("should have time")
+	}
+}
+
+func TestCommandLog_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prlctl.log")
+
+	l, err := openCommandLog(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("closing twice should not fail: %s", err)
+	}
+
+	// Commands recorded after closing are discarded
+	if err := l.Record([]string{"list", "-a"}, 0); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(data) != 0 {
+		t.Fatalf("bad: %q", data)
+	}
+}
+
+func TestCommandLog_selectedDriver(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prlctl.log")
+	script := filepath.Join(dir, "prlctl")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	l, err := openCommandLog(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// NewDriver sets the log on the driver it selected
+	var driver Driver = &Parallels11Driver{
+		Parallels9Driver: Parallels9Driver{PrlctlPath: script},
+	}
+	driver.(interface{ setCommandLog(*commandLog) }).setCommandLog(l)
+	if err := driver.Prlctl("set", "foo", "--cpus", "2"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := driver.Close(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(data), `"--cpus","2"`) {
+		t.Fatalf("bad: %s", data)
+	}
+}
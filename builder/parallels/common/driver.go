@@ -4,10 +4,12 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -23,6 +25,9 @@ import (
 // versions out of the builder steps, so sometimes the methods are
 // extremely specific.
 type Driver interface {
+	// Close releases the resources held by the driver.
+	Close() error
+
 	// Compact a virtual disk image.
 	CompactDisk(string) error
 
@@ -32,18 +37,36 @@ type Driver interface {
 	// Get path to the first virtual disk image
 	DiskPath(string) (string, error)
 
+	// Get the hardware settings of the VM stored at the given path
+	GetVMHardware(string) (map[string]string, error)
+
+	// Get the distributions a VM can be created with
+	GuestOSTypes() ([]string, error)
+
 	// Import a VM
 	Import(string, string, string, bool) error
 
+	// Checks if a VM with the given name is registered in Parallels Desktop.
+	IsRegistered(string) (bool, error)
+
 	// Checks if the VM with the given name is running.
 	IsRunning(string) (bool, error)
 
+	// Checks if host sharing is enabled for the VM with the given name.
+	SharedFoldersEnabled(string) (bool, error)
+
+	// Get the view the VM is started in, such as "window" or "headless".
+	StartupView(string) (string, error)
+
 	// Stop stops a running machine, forcefully.
 	Stop(string) error
 
 	// Prlctl executes the given Prlctl command
 	Prlctl(...string) error
 
+	// Resize a virtual disk image to the given size in megabytes.
+	ResizeDisk(string, uint) error
+
 	// Get the path to the Parallels Tools ISO for the given flavor.
 	ToolsISOPath(string) (string, error)
 
@@ -68,11 +91,32 @@ type Driver interface {
 	IPAddress(string, string) (string, error)
 }
 
+// The directory of the Parallels Desktop command line tools inside the
+// application bundle, used when they are not on the PATH.
+const parallelsAppToolsDir = "/Applications/Parallels Desktop.app/Contents/MacOS"
+
+// findParallelsTool looks up the given Parallels command line tool on the
+// PATH, falling back to the given directory.
+func findParallelsTool(name, fallbackDir string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err == nil {
+		return path, nil
+	}
+
+	fallback := filepath.Join(fallbackDir, name)
+	if _, statErr := os.Stat(fallback); statErr == nil {
+		return fallback, nil
+	}
+
+	return "", err
+}
+
 // NewDriver returns a new driver implementation for this version of Parallels
-// Desktop, or an error if the driver couldn't be initialized.
-func NewDriver() (Driver, error) {
+// Desktop, or an error if the driver couldn't be initialized. Retries of
+// failed commands are abandoned once the given context is cancelled.
+func NewDriver(ctx context.Context, config *DriverConfig) (Driver, error) {
 	var drivers map[string]Driver
-	var prlctlPath string
+	prlctlPath := config.PrlctlPath
 	var prlsrvctlPath string
 	var supportedVersions []string
 	DHCPLeaseFile := "/Library/Preferences/Parallels/parallels_dhcp_leases"
@@ -84,7 +128,7 @@ func NewDriver() (Driver, error) {
 
 	if prlctlPath == "" {
 		var err error
-		prlctlPath, err = exec.LookPath("prlctl")
+		prlctlPath, err = findParallelsTool("prlctl", parallelsAppToolsDir)
 		if err != nil {
 			return nil, err
 		}
@@ -98,7 +142,7 @@ func NewDriver() (Driver, error) {
 
 	if prlsrvctlPath == "" {
 		var err error
-		prlsrvctlPath, err = exec.LookPath("prlsrvctl")
+		prlsrvctlPath, err = findParallelsTool("prlsrvctl", filepath.Dir(prlctlPath))
 		if err != nil {
 			return nil, err
 		}
@@ -106,51 +150,75 @@ func NewDriver() (Driver, error) {
 
 	log.Printf("prlsrvctl path: %s", prlsrvctlPath)
 
+	baseDriver := Parallels9Driver{
+		PrlctlPath:    prlctlPath,
+		PrlsrvctlPath: prlsrvctlPath,
+		dhcpLeaseFile: DHCPLeaseFile,
+
+		retries:        config.DriverRetries,
+		retryDelay:     config.DriverRetryDelay,
+		commandTimeout: config.DriverCommandTimeout,
+		ctx:            ctx,
+	}
+
 	drivers = map[string]Driver{
 		"11": &Parallels11Driver{
-			Parallels9Driver: Parallels9Driver{
-				PrlctlPath:    prlctlPath,
-				PrlsrvctlPath: prlsrvctlPath,
-				dhcpLeaseFile: DHCPLeaseFile,
-			},
+			Parallels9Driver: baseDriver,
 		},
 		"10": &Parallels10Driver{
-			Parallels9Driver: Parallels9Driver{
-				PrlctlPath:    prlctlPath,
-				PrlsrvctlPath: prlsrvctlPath,
-				dhcpLeaseFile: DHCPLeaseFile,
-			},
-		},
-		"9": &Parallels9Driver{
-			PrlctlPath:    prlctlPath,
-			PrlsrvctlPath: prlsrvctlPath,
-			dhcpLeaseFile: DHCPLeaseFile,
+			Parallels9Driver: baseDriver,
 		},
+		"9": &baseDriver,
 	}
 
+	var driver Driver
 	for v, d := range drivers {
 		version, _ := d.Version()
 		if strings.HasPrefix(version, v) {
 			if err := d.Verify(); err != nil {
 				return nil, err
 			}
-			return d, nil
+			driver = d
+			break
 		}
 		supportedVersions = append(supportedVersions, v)
 	}
 
-	latestDriver := 11
-	version, _ := drivers[strconv.Itoa(latestDriver)].Version()
-	majVer, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
-	log.Printf("Parallels version: %s", version)
-	if majVer > latestDriver {
+	if driver == nil {
+		latestDriver := 11
+		version, _ := drivers[strconv.Itoa(latestDriver)].Version()
+		majVer, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+		log.Printf("Parallels version: %s", version)
+		if majVer <= latestDriver {
+			return nil, fmt.Errorf(
+				"Unable to initialize any driver. Supported Parallels Desktop versions: "+
+					"%s\n", strings.Join(supportedVersions, ", "))
+		}
 		log.Printf("Your version of Parallels Desktop for Mac is %s, Packer will use driver for version %d.", version, latestDriver)
-		return drivers[strconv.Itoa(latestDriver)], nil
+		driver = drivers[strconv.Itoa(latestDriver)]
 	}
 
-	return nil, fmt.Errorf(
-		"Unable to initialize any driver. Supported Parallels Desktop versions: "+
-			"%s\n", strings.Join(supportedVersions, ", "))
+	// The command log is opened last, so that it isn't leaked if no driver
+	// can be used
+	if config.CommandLogFile != "" {
+		cmdLog, err := openCommandLog(config.CommandLogFile)
+		if err != nil {
+			return nil, fmt.Errorf("Could not open prlctl command log: %s", err)
+		}
+		log.Printf("prlctl command log: %s", config.CommandLogFile)
+		driver.(interface{ setCommandLog(*commandLog) }).setCommandLog(cmdLog)
+	}
+
+	return driver, nil
+}
+
+// NewCheckDriver returns a driver like NewDriver for checks made before the
+// build starts. The command log is not opened, since it records the commands
+// of the build only.
+func NewCheckDriver(config *DriverConfig) (Driver, error) {
+	checkConfig := *config
+	checkConfig.CommandLogFile = ""
+	return NewDriver(context.Background(), &checkConfig)
 }
 
 // checks for pyhton SDK if if version is less than 19.0.0
@@ -5,7 +5,6 @@ package common
 
 import (
 	"fmt"
-	"os/exec"
 	"regexp"
 )
 
@@ -18,13 +17,17 @@ type Parallels11Driver struct {
 // Verify raises an error if the builder could not be used on that host machine.
 func (d *Parallels11Driver) Verify() error {
 
-	stdout, err := exec.Command(d.PrlsrvctlPath, "info", "--license").Output()
+	stdout, err := d.run(driverCommand{
+		path:  d.PrlsrvctlPath,
+		args:  []string{"info", "--license"},
+		retry: true,
+	})
 	if err != nil {
 		return err
 	}
 
 	editionRe := regexp.MustCompile(`edition="(\w+)"`)
-	matches := editionRe.FindStringSubmatch(string(stdout))
+	matches := editionRe.FindStringSubmatch(stdout)
 	if matches == nil {
 		return fmt.Errorf(
 			"Could not determine your Parallels Desktop edition using: %s info --license", d.PrlsrvctlPath)
@@ -5,6 +5,7 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -16,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/ChrisTrenkamp/goxpath"
 	"github.com/ChrisTrenkamp/goxpath/tree/xmltree"
@@ -71,20 +73,12 @@ func (d *Parallels9Driver) sendJsonScancodes(vmName string, inputScanCodes []str
 	}
 	log.Printf("complete scancode data in JSON format %s", jsonFormat)
 
-	var stdout, stderr bytes.Buffer
-	cmd := exec.Command(d.PrlctlPath, "send-key-event", vmName, "-j")
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	cmd.Stdin = strings.NewReader(string(jsonFormat))
-	err = cmd.Run()
-	stdoutString := strings.TrimSpace(stdout.String())
-	stderrString := strings.TrimSpace(stderr.String())
-	if _, ok := err.(*exec.ExitError); ok {
-		err = fmt.Errorf("prlctl error: %s", stderrString)
-	}
-	log.Printf("stdout: %s", stdoutString)
-	log.Printf("stderr: %s", stderrString)
-
+	// Typing the keys again would repeat them, so this is never retried
+	_, err = d.run(driverCommand{
+		path:  d.PrlctlPath,
+		args:  []string{"send-key-event", vmName, "-j"},
+		stdin: jsonFormat,
+	})
 	if err != nil {
 		log.Println(err)
 		return err
@@ -102,6 +96,18 @@ type Parallels9Driver struct {
 
 	// The path to the parallels_dhcp_leases file
 	dhcpLeaseFile string
+
+	// The log where executed prlctl commands are recorded, if any
+	commandLog *commandLog
+
+	// The number of retries of a failed command, the delay before the first
+	// retry, and the time limit of a single attempt (0 for none)
+	retries        int
+	retryDelay     time.Duration
+	commandTimeout time.Duration
+
+	// The context of the build. Retries are abandoned once it is cancelled.
+	ctx context.Context
 }
 
 // Import creates a clone of the source VM and reassigns the MAC address if needed.
@@ -169,6 +175,80 @@ func getConfigValueFromXpath(path, xpath string) (string, error) {
 	return node.String(), nil
 }
 
+// vmHardwareXpaths maps the hardware settings reported by GetVMHardware to
+// their location in the config.pvs file of a VM.
+var vmHardwareXpaths = map[string]string{
+	"cpus":            "/ParallelsVirtualMachine/Hardware/Cpu/Number",
+	"memory":          "/ParallelsVirtualMachine/Hardware/Memory/RAM",
+	"video_memory":    "/ParallelsVirtualMachine/Hardware/Video/VideoMemorySize",
+	"network_adapter": "/ParallelsVirtualMachine/Hardware/NetworkAdapter[@id='0']/AdapterType",
+	"hdd_interface":   "/ParallelsVirtualMachine/Hardware/Hdd[@id='0']/InterfaceType",
+	"hdd_size":        "/ParallelsVirtualMachine/Hardware/Hdd[@id='0']/Size",
+}
+
+// GetVMHardware reads the hardware settings of the VM stored at the given
+// path. Settings which are not present in the VM config are returned as
+// empty strings.
+func (d *Parallels9Driver) GetVMHardware(path string) (map[string]string, error) {
+	file, err := os.Open(path + "/config.pvs")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	doc, err := xmltree.ParseXML(file)
+	if err != nil {
+		return nil, err
+	}
+
+	hardware := make(map[string]string, len(vmHardwareXpaths))
+	for name, xpath := range vmHardwareXpaths {
+		node, err := goxpath.MustParse(xpath).Exec(doc)
+		if err != nil {
+			return nil, err
+		}
+		hardware[name] = node.String()
+	}
+
+	return hardware, nil
+}
+
+// GuestOSTypes returns the distributions accepted by the "--distribution"
+// option of "prlctl create".
+func (d *Parallels9Driver) GuestOSTypes() ([]string, error) {
+	out, err := d.run(driverCommand{
+		path:  d.PrlctlPath,
+		args:  []string{"create", "x", "--distribution", "list"},
+		retry: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The distributions follow a header line such as "The following values
+	// are allowed:", separated by newlines or commas.
+	nameRe := regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+	var osTypes []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, ":") {
+			continue
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		}) {
+			if nameRe.MatchString(field) {
+				osTypes = append(osTypes, field)
+			}
+		}
+	}
+	if len(osTypes) == 0 {
+		return nil, fmt.Errorf(
+			"Could not find any distributions in the output:\n%s", out)
+	}
+
+	return osTypes, nil
+}
+
 // Finds an application bundle by identifier (for "darwin" platform only)
 func getAppPath(bundleID string) (string, error) {
 	var stdout bytes.Buffer
@@ -196,7 +276,7 @@ func getAppPath(bundleID string) (string, error) {
 
 // CompactDisk performs the compaction of the specified virtual disk image.
 func (d *Parallels9Driver) CompactDisk(diskPath string) error {
-	prlDiskToolPath, err := exec.LookPath("prl_disk_tool")
+	prlDiskToolPath, err := findParallelsTool("prl_disk_tool", filepath.Dir(d.PrlctlPath))
 	if err != nil {
 		return err
 	}
@@ -206,7 +286,7 @@ func (d *Parallels9Driver) CompactDisk(diskPath string) error {
 		"compact",
 		"--hdd", diskPath,
 	}
-	if err := exec.Command(prlDiskToolPath, command...).Run(); err != nil {
+	if _, err := d.run(driverCommand{path: prlDiskToolPath, args: command, retry: true}); err != nil {
 		return err
 	}
 
@@ -215,13 +295,31 @@ func (d *Parallels9Driver) CompactDisk(diskPath string) error {
 		"compact", "--buildmap",
 		"--hdd", diskPath,
 	}
-	if err := exec.Command(prlDiskToolPath, command...).Run(); err != nil {
+	if _, err := d.run(driverCommand{path: prlDiskToolPath, args: command, retry: true}); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// ResizeDisk expands the specified virtual disk image to the given size in
+// megabytes.
+func (d *Parallels9Driver) ResizeDisk(diskPath string, sizeMB uint) error {
+	prlDiskToolPath, err := findParallelsTool("prl_disk_tool", filepath.Dir(d.PrlctlPath))
+	if err != nil {
+		return err
+	}
+
+	command := []string{
+		"resize",
+		"--hdd", diskPath,
+		"--size", fmt.Sprintf("%dM", sizeMB),
+	}
+	// The size is absolute, so resizing again is safe
+	_, err = d.run(driverCommand{path: prlDiskToolPath, args: command, retry: true})
+	return err
+}
+
 // DeviceAddCDROM adds a virtual CDROM device and attaches the specified image.
 func (d *Parallels9Driver) DeviceAddCDROM(name string, image string) (string, error) {
 	command := []string{
@@ -231,16 +329,16 @@ func (d *Parallels9Driver) DeviceAddCDROM(name string, image string) (string, er
 		"--enable", "--connect",
 	}
 
-	out, err := exec.Command(d.PrlctlPath, command...).Output()
+	out, err := d.run(driverCommand{path: d.PrlctlPath, args: command, record: true})
 	if err != nil {
 		return "", err
 	}
 
 	deviceRe := regexp.MustCompile(`\s+(cdrom\d+)\s+`)
-	matches := deviceRe.FindStringSubmatch(string(out))
+	matches := deviceRe.FindStringSubmatch(out)
 	if matches == nil {
 		return "", fmt.Errorf(
-			"Could not determine cdrom device name in the output:\n%s", string(out))
+			"Could not determine cdrom device name in the output:\n%s", out)
 	}
 
 	deviceName := matches[1]
@@ -249,35 +347,48 @@ func (d *Parallels9Driver) DeviceAddCDROM(name string, image string) (string, er
 
 // DiskPath returns a full path to the first virtual disk drive.
 func (d *Parallels9Driver) DiskPath(name string) (string, error) {
-	out, err := exec.Command(d.PrlctlPath, "list", "-i", name).Output()
+	out, err := d.list("-i", name)
 	if err != nil {
 		return "", err
 	}
 
 	HDDRe := regexp.MustCompile("hdd0.* image='(.*)' type=*")
-	matches := HDDRe.FindStringSubmatch(string(out))
+	matches := HDDRe.FindStringSubmatch(out)
 	if matches == nil {
 		return "", fmt.Errorf(
-			"Could not determine hdd image path in the output:\n%s", string(out))
+			"Could not determine hdd image path in the output:\n%s", out)
 	}
 
 	HDDPath := matches[1]
 	return HDDPath, nil
 }
 
+// IsRegistered determines whether a VM with the given name is registered.
+func (d *Parallels9Driver) IsRegistered(name string) (bool, error) {
+	out, err := d.list("--all", "--no-header", "--output", "name")
+	if err != nil {
+		return false, err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // IsRunning determines whether the VM is running or not.
 func (d *Parallels9Driver) IsRunning(name string) (bool, error) {
-	var stdout bytes.Buffer
-
-	cmd := exec.Command(d.PrlctlPath, "list", name, "--no-header", "--output", "status")
-	cmd.Stdout = &stdout
-	if err := cmd.Run(); err != nil {
+	out, err := d.list(name, "--no-header", "--output", "status")
+	if err != nil {
 		return false, err
 	}
 
-	log.Printf("Checking VM state: %s\n", strings.TrimSpace(stdout.String()))
+	log.Printf("Checking VM state: %s\n", strings.TrimSpace(out))
 
-	for _, line := range strings.Split(stdout.String(), "\n") {
+	for _, line := range strings.Split(out, "\n") {
 		if line == "running" {
 			return true, nil
 		}
@@ -296,6 +407,40 @@ func (d *Parallels9Driver) IsRunning(name string) (bool, error) {
 	return false, nil
 }
 
+// StartupView returns the view the VM is started in.
+func (d *Parallels9Driver) StartupView(name string) (string, error) {
+	out, err := d.list("-i", name)
+	if err != nil {
+		return "", err
+	}
+
+	viewRe := regexp.MustCompile(`(?m)^\s*Startup view: (\S+)`)
+	matches := viewRe.FindStringSubmatch(out)
+	if matches == nil {
+		return "", fmt.Errorf(
+			"Could not determine startup view in the output:\n%s", out)
+	}
+
+	return matches[1], nil
+}
+
+// SharedFoldersEnabled reports whether host sharing is enabled for the VM.
+func (d *Parallels9Driver) SharedFoldersEnabled(name string) (bool, error) {
+	out, err := d.list("-i", name)
+	if err != nil {
+		return false, err
+	}
+
+	sharingRe := regexp.MustCompile(`(?m)^\s*Host Shared Folders: \(([+-])\)`)
+	matches := sharingRe.FindStringSubmatch(out)
+	if matches == nil {
+		return false, fmt.Errorf(
+			"Could not determine the host sharing state in the output:\n%s", out)
+	}
+
+	return matches[1] == "+", nil
+}
+
 // Stop forcibly stops the VM.
 func (d *Parallels9Driver) Stop(name string) error {
 	if err := d.Prlctl("stop", name, "--kill"); err != nil {
@@ -308,27 +453,157 @@ func (d *Parallels9Driver) Stop(name string) error {
 	return nil
 }
 
-// Prlctl executes the specified "prlctl" command.
+// Prlctl executes the specified "prlctl" command. Failed commands that are
+// safe to repeat are retried if retries are configured.
 func (d *Parallels9Driver) Prlctl(args ...string) error {
+	_, err := d.run(driverCommand{
+		path:   d.PrlctlPath,
+		args:   args,
+		retry:  prlctlRepeatable(args),
+		record: true,
+	})
+	return err
+}
+
+// list executes the given "prlctl list" command and returns its output.
+// Listing only reads the state of Parallels Desktop, so it is always safe to
+// retry.
+func (d *Parallels9Driver) list(args ...string) (string, error) {
+	return d.run(driverCommand{
+		path:  d.PrlctlPath,
+		args:  append([]string{"list"}, args...),
+		retry: true,
+	})
+}
+
+// prlctlRepeatable reports whether the given prlctl command can be executed
+// again after a failed attempt, which may have been partially applied. This
+// is the case for commands which only read state, and for settings changes
+// which don't add or remove devices or shared folders.
+func prlctlRepeatable(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "list", "status", "snapshot-list", "--version":
+		return true
+	case "set":
+		for _, arg := range args[1:] {
+			for _, prefix := range []string{"--device-add", "--device-del", "--shf-host-add", "--shf-host-del"} {
+				if strings.HasPrefix(arg, prefix) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// driverCommand is a command executed by the driver.
+type driverCommand struct {
+	// The program to execute and its arguments
+	path string
+	args []string
+
+	// The data written to the standard input of the command, if any
+	stdin []byte
+
+	// Whether the command may be retried after a failed attempt
+	retry bool
+
+	// Whether the command is recorded in the prlctl command log
+	record bool
+}
+
+// run executes the given command and returns its standard output. Every
+// attempt is limited by the command timeout. Commands that may be retried
+// are attempted again with an exponential backoff, until the retries are
+// used up or the build is cancelled.
+func (d *Parallels9Driver) run(c driverCommand) (string, error) {
+	var cancelled <-chan struct{}
+	if d.ctx != nil {
+		cancelled = d.ctx.Done()
+	}
+
+	delay := d.retryDelay
+	for attempt := 0; ; attempt++ {
+		out, err := d.runOnce(c)
+		if err == nil || !c.retry || attempt >= d.retries {
+			return out, err
+		}
+
+		log.Printf("%s attempt %d of %d failed: %s. Retrying in %s...",
+			filepath.Base(c.path), attempt+1, d.retries+1, err, delay)
+		select {
+		case <-time.After(delay):
+		case <-cancelled:
+			return out, err
+		}
+		delay *= 2
+	}
+}
+
+// runOnce executes a single attempt of the given command.
+func (d *Parallels9Driver) runOnce(c driverCommand) (string, error) {
 	var stdout, stderr bytes.Buffer
+	name := filepath.Base(c.path)
+
+	ctx := context.Background()
+	if d.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.commandTimeout)
+		defer cancel()
+	}
 
-	log.Printf("Executing prlctl: %#v", args)
-	cmd := exec.Command(d.PrlctlPath, args...)
+	log.Printf("Executing %s: %#v", name, c.args)
+	cmd := exec.CommandContext(ctx, c.path, c.args...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if c.stdin != nil {
+		cmd.Stdin = bytes.NewReader(c.stdin)
+	}
 	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s timed out after %s", name, d.commandTimeout)
+	}
 
 	stdoutString := strings.TrimSpace(stdout.String())
 	stderrString := strings.TrimSpace(stderr.String())
 
-	if _, ok := err.(*exec.ExitError); ok {
-		err = fmt.Errorf("prlctl error: %s", stderrString)
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+		err = fmt.Errorf("%s error: %s", name, stderrString)
+	} else if err != nil {
+		exitCode = -1
 	}
 
 	log.Printf("stdout: %s", stdoutString)
 	log.Printf("stderr: %s", stderrString)
 
-	return err
+	if c.record && d.commandLog != nil {
+		if logErr := d.commandLog.Record(c.args, exitCode); logErr != nil {
+			log.Printf("Error writing to the prlctl command log: %s", logErr)
+		}
+	}
+
+	return stdout.String(), err
+}
+
+// setCommandLog sets the log the executed prlctl commands are recorded in.
+func (d *Parallels9Driver) setCommandLog(commandLog *commandLog) {
+	d.commandLog = commandLog
+}
+
+// Close closes the prlctl command log, if any.
+func (d *Parallels9Driver) Close() error {
+	if d.commandLog == nil {
+		return nil
+	}
+	return d.commandLog.Close()
 }
 
 // Verify raises an error if the builder could not be used on that host machine.
@@ -338,16 +613,20 @@ func (d *Parallels9Driver) Verify() error {
 
 // Version returns the version of Parallels Desktop installed on that host.
 func (d *Parallels9Driver) Version() (string, error) {
-	out, err := exec.Command(d.PrlctlPath, "--version").Output()
+	out, err := d.run(driverCommand{
+		path:  d.PrlctlPath,
+		args:  []string{"--version"},
+		retry: true,
+	})
 	if err != nil {
 		return "", err
 	}
 
 	versionRe := regexp.MustCompile(`prlctl version (\d+\.\d+.\d+)`)
-	matches := versionRe.FindStringSubmatch(string(out))
+	matches := versionRe.FindStringSubmatch(out)
 	if matches == nil {
 		return "", fmt.Errorf(
-			"Could not find Parallels Desktop version in output:\n%s", string(out))
+			"Could not find Parallels Desktop version in output:\n%s", out)
 	}
 
 	version := matches[1]
@@ -358,7 +637,6 @@ func (d *Parallels9Driver) Version() (string, error) {
 // It is performed using "Prltype" script (refer to "prltype.go") if version is  < 19.0.0.
 // scancodes are sent by using prlctl CMD if version is  >= 19.0.0
 func (d *Parallels9Driver) SendKeyScanCodes(vmName string, codes ...string) error {
-	var stdout, stderr bytes.Buffer
 	var err error
 
 	if len(codes) == 0 {
@@ -389,21 +667,9 @@ func (d *Parallels9Driver) SendKeyScanCodes(vmName string, codes ...string) erro
 
 		args := prepend(vmName, codes)
 		args = prepend(f.Name(), args)
-		cmd := exec.Command("/usr/bin/python3", args...)
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
-		err = cmd.Run()
-
-		stdoutString := strings.TrimSpace(stdout.String())
-		stderrString := strings.TrimSpace(stderr.String())
-
-		if _, ok := err.(*exec.ExitError); ok {
-			err = fmt.Errorf("prltype error: %s", stderrString)
+		if _, err := d.run(driverCommand{path: "/usr/bin/python3", args: args}); err != nil {
 			return err
 		}
-
-		log.Printf("stdout: %s", stdoutString)
-		log.Printf("stderr: %s", stderrString)
 	} else {
 		err = d.sendJsonScancodes(vmName, codes)
 	}
@@ -439,16 +705,13 @@ func (d *Parallels9Driver) SetDefaultConfiguration(vmName string) error {
 
 // MAC returns the MAC address of the VM's first network interface.
 func (d *Parallels9Driver) MAC(vmName string) (string, error) {
-	var stdout bytes.Buffer
-
-	cmd := exec.Command(d.PrlctlPath, "list", "-i", vmName)
-	cmd.Stdout = &stdout
-	if err := cmd.Run(); err != nil {
+	out, err := d.list("-i", vmName)
+	if err != nil {
 		log.Printf("MAC address for NIC: nic0 on Virtual Machine: %s not found!\n", vmName)
 		return "", err
 	}
 
-	stdoutString := strings.TrimSpace(stdout.String())
+	stdoutString := strings.TrimSpace(out)
 	re := regexp.MustCompile("net0.* mac=([0-9A-F]{12}) card=.*")
 	macMatch := re.FindAllStringSubmatch(stdoutString, 1)
 
@@ -496,15 +759,13 @@ func (d *Parallels9Driver) IPAddress(mac string, vmName string) (string, error)
 	if len(mostRecentIP) == 0 {
 		log.Printf("IP lease not found for MAC address %s in: %s\n", mac, d.dhcpLeaseFile)
 
-		var stdout bytes.Buffer
-		cmd := exec.Command(d.PrlctlPath, "list", vmName, "--full", "--no-header", "-o", "ip_configured")
-		cmd.Stdout = &stdout
-		if err := cmd.Run(); err != nil {
+		out, err := d.list(vmName, "--full", "--no-header", "-o", "ip_configured")
+		if err != nil {
 			log.Printf("Command run failed for Virtual Machine: %s\n", vmName)
 			return "", err
 		}
 
-		stdoutString := strings.TrimSpace(stdout.String())
+		stdoutString := strings.TrimSpace(out)
 		re := regexp.MustCompile(`([0-9]+\.[0-9]+\.[0-9]+\.[0-9]+)`)
 		macMatch := re.FindAllStringSubmatch(stdoutString, 1)
 
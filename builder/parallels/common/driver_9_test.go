@@ -4,9 +4,14 @@
 package common
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParallels9Driver_impl(t *testing.T) {
@@ -88,3 +93,277 @@ func TestXMLParseConfig(t *testing.T) {
 		t.Fatalf("Expected %q, got %q", "20", result)
 	}
 }
+
+func TestGetVMHardware(t *testing.T) {
+	d := Parallels9Driver{}
+	hardware, err := d.GetVMHardware("testdata/test.pvm")
+	if err != nil {
+		t.Fatalf("Error reading hardware: %s", err)
+	}
+
+	expected := map[string]string{
+		"cpus":            "2",
+		"memory":          "2048",
+		"video_memory":    "32",
+		"network_adapter": "2",
+		"hdd_interface":   "1",
+		"hdd_size":        "65536",
+	}
+	if !reflect.DeepEqual(hardware, expected) {
+		t.Fatalf("Expected %#v, got %#v", expected, hardware)
+	}
+}
+
+func TestGetVMHardware_missing(t *testing.T) {
+	td := t.TempDir()
+	config := []byte(`
+<ParallelsVirtualMachine>
+  <Hardware>
+    <Cpu>
+      <Number>2</Number>
+    </Cpu>
+  </Hardware>
+</ParallelsVirtualMachine>
+`)
+	_ = ioutil.WriteFile(td+"/config.pvs", config, 0666)
+
+	d := Parallels9Driver{}
+	hardware, err := d.GetVMHardware(td)
+	if err != nil {
+		t.Fatalf("Error reading hardware: %s", err)
+	}
+
+	// Settings missing from the config are reported as empty strings
+	if hardware["cpus"] != "2" || hardware["memory"] != "" || hardware["hdd_size"] != "" {
+		t.Fatalf("bad: %#v", hardware)
+	}
+
+	if _, err := d.GetVMHardware(filepath.Join(td, "missing.pvm")); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestPrlctl_Retries(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "attempts")
+
+	// A fake prlctl that fails on its first two invocations
+	script := filepath.Join(dir, "prlctl")
+	content := "#!/bin/sh\n" +
+		"echo x >> " + counter + "\n" +
+		"[ $(wc -l < " + counter + ") -gt 2 ] || { echo locked >&2; exit 1; }\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := &Parallels9Driver{
+		PrlctlPath: script,
+		retries:    1,
+		retryDelay: time.Millisecond,
+	}
+	if err := d.Prlctl("set", "foo", "--cpus", "2"); err == nil {
+		t.Fatal("should have error")
+	}
+
+	os.Remove(counter)
+	d.retries = 2
+	if err := d.Prlctl("set", "foo", "--cpus", "2"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Commands that are not safe to repeat are not retried
+	os.Remove(counter)
+	if err := d.Prlctl("start", "foo"); err == nil {
+		t.Fatal("should have error")
+	}
+	data, _ := os.ReadFile(counter)
+	if attempts := strings.Count(string(data), "x"); attempts != 1 {
+		t.Fatalf("bad attempts: %d", attempts)
+	}
+
+	// Read-only driver methods are retried as well
+	os.Remove(counter)
+	if _, err := d.IsRunning("foo"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestPrlctl_RetriesCancelled(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "prlctl")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	d := &Parallels9Driver{
+		PrlctlPath: script,
+		retries:    3,
+		retryDelay: time.Minute,
+		ctx:        ctx,
+	}
+
+	// The backoff is abandoned once the build is cancelled
+	done := make(chan error, 1)
+	go func() {
+		done <- d.Prlctl("set", "foo", "--cpus", "2")
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("should have error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry should have been abandoned")
+	}
+}
+
+func TestPrlctlRepeatable(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"list", "-i", "foo"}, true},
+		{[]string{"set", "foo", "--cpus", "2"}, true},
+		{[]string{"set", "foo", "--device-set", "cdrom0", "--image", ""}, true},
+		{[]string{"set", "foo", "--device-add", "serial"}, false},
+		{[]string{"set", "foo", "--device-add-sound", "--connect"}, false},
+		{[]string{"set", "foo", "--device-del", "serial0"}, false},
+		{[]string{"set", "foo", "--shf-host-add", "src", "--path", "/src"}, false},
+		{[]string{"create", "foo", "--distribution", "ubuntu"}, false},
+		{[]string{"register", "foo.pvm"}, false},
+		{[]string{"snapshot", "foo", "--name", "base"}, false},
+		{[]string{"start", "foo"}, false},
+		{nil, false},
+	}
+
+	for _, tc := range cases {
+		if actual := prlctlRepeatable(tc.args); actual != tc.expected {
+			t.Errorf("%#v: expected %t, got %t", tc.args, tc.expected, actual)
+		}
+	}
+}
+
+func TestResizeDisk_toolFallback(t *testing.T) {
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+
+	// prl_disk_tool is found next to prlctl if it is not on the PATH
+	script := filepath.Join(dir, "prl_disk_tool")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" > "+args+"\n"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	d := &Parallels9Driver{PrlctlPath: filepath.Join(dir, "prlctl")}
+	if err := d.ResizeDisk("/foo.hdd", 2048); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(data) != "resize --hdd /foo.hdd --size 2048M\n" {
+		t.Fatalf("bad: %q", data)
+	}
+}
+
+func TestPrlctl_Timeout(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "prlctl")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := &Parallels9Driver{
+		PrlctlPath:     script,
+		commandTimeout: 100 * time.Millisecond,
+	}
+	err := d.Prlctl("start", "foo")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestStartupView(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "prlctl")
+	content := "#!/bin/sh\n" +
+		"echo 'Startup and Shutdown:'\n" +
+		"echo '  Autostart: off'\n" +
+		"echo '  Startup view: window'\n" +
+		"echo '  On shutdown: close'\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := &Parallels9Driver{PrlctlPath: script}
+	view, err := d.StartupView("foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if view != "window" {
+		t.Fatalf("bad: %s", view)
+	}
+}
+
+func TestSharedFoldersEnabled(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "prlctl")
+	content := "#!/bin/sh\n" +
+		"echo 'Host Shared Folders: (+)'\n" +
+		"echo '  payload (+) path=\"/tmp/payload\" mode=ro'\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := &Parallels9Driver{PrlctlPath: script}
+	enabled, err := d.SharedFoldersEnabled("foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !enabled {
+		t.Fatal("host sharing should be enabled")
+	}
+}
+
+func TestGuestOSTypes(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "prlctl")
+	content := "#!/bin/sh\n" +
+		"echo 'The following values are allowed:'\n" +
+		"echo 'win-11, ubuntu'\n" +
+		"echo 'fedora-core'\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := &Parallels9Driver{PrlctlPath: script}
+	osTypes, err := d.GuestOSTypes()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{"win-11", "ubuntu", "fedora-core"}
+	if !reflect.DeepEqual(osTypes, expected) {
+		t.Fatalf("bad: %#v", osTypes)
+	}
+}
+
+func TestDriverCommands_Timeout(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "prlctl")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := &Parallels9Driver{
+		PrlctlPath:     script,
+		commandTimeout: 100 * time.Millisecond,
+	}
+
+	// Commands other than Prlctl are limited by the timeout as well
+	if _, err := d.IsRunning("foo"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := d.DiskPath("foo"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("bad: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// DriverConfig contains the configuration of the driver that talks to
+// Parallels Desktop.
+type DriverConfig struct {
+	// The path to a file where every prlctl command executed during the build
+	// is recorded. Each command is appended as a JSON line containing the
+	// time, the arguments and the exit code of the command. The build fails if
+	// the file cannot be opened. By default no command log is written.
+	CommandLogFile string `mapstructure:"command_log_file" required:"false"`
+	// The maximum time a single prlctl or prl_disk_tool command may run
+	// before it is killed and treated as failed, for example `5m`. By default
+	// commands run without a time limit.
+	DriverCommandTimeout time.Duration `mapstructure:"driver_command_timeout" required:"false"`
+	// The number of times a failed prlctl or prl_disk_tool command is retried
+	// before the build fails. Use this to ride out transient errors such as a
+	// VM that is briefly locked by Parallels Desktop. Only commands that are
+	// safe to repeat are retried: commands that create or register VMs, add
+	// or remove devices, take snapshots or type keys are not. Retrying stops
+	// when the build is cancelled. Defaults to 0.
+	DriverRetries int `mapstructure:"driver_retries" required:"false"`
+	// The time to wait before the first retry of a failed prlctl command. The
+	// delay doubles after every further attempt. Defaults to `2s`.
+	DriverRetryDelay time.Duration `mapstructure:"driver_retry_delay" required:"false"`
+	// The path to the `prlctl` executable. By default `prlctl` is looked up
+	// on the `PATH`, and then in the Parallels Desktop application bundle.
+	// `prlsrvctl` and `prl_disk_tool` are looked up the same way, falling
+	// back to the directory of `prlctl`.
+	PrlctlPath string `mapstructure:"prlctl_path" required:"false"`
+	// Check the host before the build starts instead of failing halfway
+	// through it: that a supported Parallels Desktop version is installed,
+	// that the file system of the output directory has room for the
+	// configured disk sizes, that a port of the HTTP port range is free, and
+	// for the iso builder that Parallels Desktop supports the `guest_os_type`.
+	// All problems are reported together. The space needed to download an
+	// ISO file into the Packer cache is not checked. Defaults to `false`.
+	ValidateEnvironment bool `mapstructure:"validate_environment" required:"false"`
+}
+
+// Prepare validates the driver configuration and sets defaults.
+func (c *DriverConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	if c.DriverRetries < 0 {
+		errs = append(errs, errors.New("driver_retries must not be negative"))
+	}
+
+	if c.DriverRetryDelay < 0 {
+		errs = append(errs, errors.New("driver_retry_delay must not be negative"))
+	}
+	if c.DriverRetryDelay == 0 {
+		c.DriverRetryDelay = 2 * time.Second
+	}
+
+	if c.DriverCommandTimeout < 0 {
+		errs = append(errs, errors.New("driver_command_timeout must not be negative"))
+	}
+
+	if c.PrlctlPath != "" {
+		if _, err := os.Stat(c.PrlctlPath); err != nil {
+			errs = append(errs, fmt.Errorf("prlctl_path is invalid: %s", err))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestDriverConfigPrepare(t *testing.T) {
+	c := new(DriverConfig)
+	errs := c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	if c.DriverRetries != 0 {
+		t.Errorf("bad driver retries: %d", c.DriverRetries)
+	}
+	if c.DriverRetryDelay != 2*time.Second {
+		t.Errorf("bad driver retry delay: %s", c.DriverRetryDelay)
+	}
+	if c.DriverCommandTimeout != 0 {
+		t.Errorf("bad driver command timeout: %s", c.DriverCommandTimeout)
+	}
+}
+
+func TestDriverConfigPrepare_Negative(t *testing.T) {
+	c := &DriverConfig{
+		DriverCommandTimeout: -time.Second,
+		DriverRetries:        -1,
+		DriverRetryDelay:     -time.Second,
+	}
+	errs := c.Prepare(interpolate.NewContext())
+	if len(errs) != 3 {
+		t.Fatalf("should have errors: %#v", errs)
+	}
+}
+
+func TestDriverConfigPrepare_PrlctlPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prlctl")
+
+	// Bad
+	c := &DriverConfig{PrlctlPath: path}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 1 {
+		t.Fatalf("should have error: %#v", errs)
+	}
+
+	// Good
+	if err := os.WriteFile(path, nil, 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	c = &DriverConfig{PrlctlPath: path}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+}
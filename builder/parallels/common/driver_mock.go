@@ -8,6 +8,9 @@ import "sync"
 type DriverMock struct {
 	sync.Mutex
 
+	CloseCalled bool
+	CloseErr    error
+
 	CompactDiskCalled bool
 	CompactDiskPath   string
 	CompactDiskErr    error
@@ -23,22 +26,47 @@ type DriverMock struct {
 	DiskPathResult string
 	DiskPathErr    error
 
+	GetVMHardwareCalled bool
+	GetVMHardwarePaths  []string
+	GetVMHardwareResult map[string]map[string]string
+	GetVMHardwareErr    error
+
+	GuestOSTypesResult []string
+	GuestOSTypesErr    error
+
 	ImportCalled  bool
 	ImportName    string
 	ImportSrcPath string
 	ImportDstPath string
 	ImportErr     error
 
+	IsRegisteredName   string
+	IsRegisteredReturn bool
+	IsRegisteredErr    error
+
 	IsRunningName   string
 	IsRunningReturn bool
 	IsRunningErr    error
 
+	SharedFoldersEnabledName   string
+	SharedFoldersEnabledResult bool
+	SharedFoldersEnabledErr    error
+
+	StartupViewName   string
+	StartupViewResult string
+	StartupViewErr    error
+
 	StopName string
 	StopErr  error
 
 	PrlctlCalls [][]string
 	PrlctlErrs  []error
 
+	ResizeDiskCalled bool
+	ResizeDiskPath   string
+	ResizeDiskSize   uint
+	ResizeDiskErr    error
+
 	VerifyCalled bool
 	VerifyErr    error
 
@@ -66,6 +94,11 @@ type DriverMock struct {
 	IPAddressError  error
 }
 
+func (d *DriverMock) Close() error {
+	d.CloseCalled = true
+	return d.CloseErr
+}
+
 func (d *DriverMock) CompactDisk(path string) error {
 	d.CompactDiskCalled = true
 	d.CompactDiskPath = path
@@ -85,6 +118,16 @@ func (d *DriverMock) DiskPath(name string) (string, error) {
 	return d.DiskPathResult, d.DiskPathErr
 }
 
+func (d *DriverMock) GetVMHardware(path string) (map[string]string, error) {
+	d.GetVMHardwareCalled = true
+	d.GetVMHardwarePaths = append(d.GetVMHardwarePaths, path)
+	return d.GetVMHardwareResult[path], d.GetVMHardwareErr
+}
+
+func (d *DriverMock) GuestOSTypes() ([]string, error) {
+	return d.GuestOSTypesResult, d.GuestOSTypesErr
+}
+
 func (d *DriverMock) Import(name, srcPath, dstPath string, reassignMAC bool) error {
 	d.ImportCalled = true
 	d.ImportName = name
@@ -93,6 +136,11 @@ func (d *DriverMock) Import(name, srcPath, dstPath string, reassignMAC bool) err
 	return d.ImportErr
 }
 
+func (d *DriverMock) IsRegistered(name string) (bool, error) {
+	d.IsRegisteredName = name
+	return d.IsRegisteredReturn, d.IsRegisteredErr
+}
+
 func (d *DriverMock) IsRunning(name string) (bool, error) {
 	d.Lock()
 	defer d.Unlock()
@@ -101,6 +149,16 @@ func (d *DriverMock) IsRunning(name string) (bool, error) {
 	return d.IsRunningReturn, d.IsRunningErr
 }
 
+func (d *DriverMock) SharedFoldersEnabled(name string) (bool, error) {
+	d.SharedFoldersEnabledName = name
+	return d.SharedFoldersEnabledResult, d.SharedFoldersEnabledErr
+}
+
+func (d *DriverMock) StartupView(name string) (string, error) {
+	d.StartupViewName = name
+	return d.StartupViewResult, d.StartupViewErr
+}
+
 func (d *DriverMock) Stop(name string) error {
 	d.StopName = name
 	return d.StopErr
@@ -115,6 +173,13 @@ func (d *DriverMock) Prlctl(args ...string) error {
 	return nil
 }
 
+func (d *DriverMock) ResizeDisk(path string, sizeMB uint) error {
+	d.ResizeDiskCalled = true
+	d.ResizeDiskPath = path
+	d.ResizeDiskSize = sizeMB
+	return d.ResizeDiskErr
+}
+
 func (d *DriverMock) Verify() error {
 	d.VerifyCalled = true
 	return d.VerifyErr
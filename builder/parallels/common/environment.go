@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// EnvironmentCheck describes the host requirements of a build, so that they
+// can be verified before the build starts.
+type EnvironmentCheck struct {
+	DriverConfig *DriverConfig
+
+	// The output directory and the disk space in megabytes the build needs
+	// in it. The space is not checked if it is 0.
+	OutputDir  string
+	DiskSizeMB uint64
+
+	// The configuration of the HTTP server. Its port range is only checked
+	// if the server is used.
+	HTTPConfig *commonsteps.HTTPConfig
+
+	// The guest OS type the VM is created with. It is not checked if it is
+	// empty.
+	GuestOSType string
+}
+
+// Validate checks that Parallels Desktop can be used, that there is enough
+// free disk space for the output directory and that a port of the HTTP port
+// range is available, and that the installed Parallels Desktop supports the
+// guest OS type. It returns all problems that were found.
+//
+// The space needed to download an ISO file into the Packer cache is not
+// checked, since its size is unknown until the download starts.
+func (c *EnvironmentCheck) Validate() []error {
+	var errs []error
+
+	if driver, err := NewCheckDriver(c.DriverConfig); err != nil {
+		errs = append(errs, fmt.Errorf("Parallels Desktop cannot be used: %s", err))
+	} else {
+		if c.GuestOSType != "" {
+			if err := checkGuestOSType(driver, c.GuestOSType); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		driver.Close()
+	}
+
+	if c.DiskSizeMB > 0 {
+		dir := existingParent(c.OutputDir)
+		free, err := freeDiskSpaceMB(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Error checking free disk space in %s: %s", dir, err))
+		} else if free >= 0 && uint64(free) < c.DiskSizeMB {
+			errs = append(errs, fmt.Errorf(
+				"Not enough free disk space in %s: %d MB needed, %d MB available",
+				dir, c.DiskSizeMB, free))
+		}
+	}
+
+	if h := c.HTTPConfig; h != nil && (h.HTTPDir != "" || len(h.HTTPContent) > 0) {
+		if !portAvailable(h.HTTPAddress, h.HTTPPortMin, h.HTTPPortMax) {
+			errs = append(errs, fmt.Errorf(
+				"No free HTTP port between %d and %d", h.HTTPPortMin, h.HTTPPortMax))
+		}
+	}
+
+	return errs
+}
+
+// existingParent returns the given path, or its closest parent that exists.
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// portAvailable reports whether any port of the given range can be bound on
+// the given address.
+func portAvailable(addr string, min, max int) bool {
+	for port := min; port <= max; port++ {
+		l, err := net.Listen("tcp", net.JoinHostPort(addr, strconv.Itoa(port)))
+		if err == nil {
+			l.Close()
+			return true
+		}
+	}
+	return false
+}
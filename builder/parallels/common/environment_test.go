@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEnvironmentCheck_DiskSpace(t *testing.T) {
+	if runtime.GOOS == "darwin" {
+		t.Skip("Parallels Desktop may be installed")
+	}
+
+	dir := t.TempDir()
+	c := &EnvironmentCheck{
+		DriverConfig: &DriverConfig{
+			CommandLogFile: filepath.Join(dir, "commands.log"),
+		},
+		OutputDir:  filepath.Join(dir, "output", "vm"),
+		DiskSizeMB: 1,
+	}
+
+	// Only the driver can't be used here
+	if errs := c.Validate(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// The command log is left to the build
+	if _, err := os.Stat(c.DriverConfig.CommandLogFile); !os.IsNotExist(err) {
+		t.Fatalf("command log should not be created: %v", err)
+	}
+
+	// Request more space than any disk has
+	c.DiskSizeMB = math.MaxInt64
+	if errs := c.Validate(); len(errs) != 2 {
+		t.Fatalf("bad: %#v", errs)
+	}
+}
+
+func TestExistingParent(t *testing.T) {
+	dir := t.TempDir()
+	if p := existingParent(filepath.Join(dir, "a", "b")); p != dir {
+		t.Fatalf("bad: %s", p)
+	}
+}
+
+func TestPortAvailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	if portAvailable("127.0.0.1", port, port) {
+		t.Fatal("port should be in use")
+	}
+	if !portAvailable("127.0.0.1", 0, 0) {
+		t.Fatal("a random port should be available")
+	}
+}
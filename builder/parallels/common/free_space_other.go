@@ -0,0 +1,13 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !darwin && !linux && !freebsd
+// +build !darwin,!linux,!freebsd
+
+package common
+
+// freeDiskSpaceMB returns -1, since the free disk space can't be determined
+// on this platform.
+func freeDiskSpaceMB(path string) (int64, error) {
+	return -1, nil
+}
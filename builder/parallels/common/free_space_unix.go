@@ -0,0 +1,19 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build darwin || linux || freebsd
+// +build darwin linux freebsd
+
+package common
+
+import "syscall"
+
+// freeDiskSpaceMB returns the disk space in megabytes available to
+// unprivileged users on the file system containing the given path.
+func freeDiskSpaceMB(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(uint64(st.Bavail) * uint64(st.Bsize) / (1024 * 1024)), nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/agext/levenshtein"
+)
+
+// SuggestGuestOSTypes returns up to three of the given known guest OS types
+// closest to the given one.
+func SuggestGuestOSTypes(osType string, known []string) []string {
+	type candidate struct {
+		name string
+		dist int
+	}
+
+	var candidates []candidate
+	for _, t := range known {
+		dist := levenshtein.Distance(osType, t, nil)
+		if dist <= 3 {
+			candidates = append(candidates, candidate{t, dist})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].dist < candidates[j].dist
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+	return suggestions
+}
+
+// checkGuestOSType checks that the given guest OS type is one of the
+// distributions supported by the Parallels Desktop version of the driver.
+func checkGuestOSType(driver Driver, osType string) error {
+	osTypes, err := driver.GuestOSTypes()
+	if err != nil {
+		return fmt.Errorf("Error reading the supported guest OS types: %s", err)
+	}
+
+	for _, t := range osTypes {
+		if t == osType {
+			return nil
+		}
+	}
+
+	msg := fmt.Sprintf("guest_os_type %q is not supported by the installed "+
+		"Parallels Desktop.", osType)
+	if suggestions := SuggestGuestOSTypes(osType, osTypes); len(suggestions) > 0 {
+		msg += fmt.Sprintf(" Did you mean %s?", strings.Join(suggestions, ", "))
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuggestGuestOSTypes(t *testing.T) {
+	known := []string{"debian", "ubuntu", "win-10", "win-11"}
+
+	if s := SuggestGuestOSTypes("ubunto", known); !reflect.DeepEqual(s, []string{"ubuntu"}) {
+		t.Fatalf("bad: %#v", s)
+	}
+	if s := SuggestGuestOSTypes("win-12", known); !reflect.DeepEqual(s, []string{"win-10", "win-11"}) {
+		t.Fatalf("bad: %#v", s)
+	}
+	if s := SuggestGuestOSTypes("solaris", known); len(s) != 0 {
+		t.Fatalf("bad: %#v", s)
+	}
+}
+
+func TestCheckGuestOSType(t *testing.T) {
+	driver := &DriverMock{GuestOSTypesResult: []string{"ubuntu", "win-2025"}}
+
+	// Distributions newer than the built-in list are accepted
+	if err := checkGuestOSType(driver, "win-2025"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err := checkGuestOSType(driver, "ubunto")
+	if err == nil || !strings.Contains(err.Error(), "Did you mean ubuntu?") {
+		t.Fatalf("bad: %v", err)
+	}
+
+	driver.GuestOSTypesErr = errors.New("foo")
+	if err := checkGuestOSType(driver, "ubuntu"); err == nil {
+		t.Fatal("should have error")
+	}
+}
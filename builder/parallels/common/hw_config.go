@@ -15,15 +15,32 @@ type HWConfig struct {
 	// The number of cpus to use for building the VM.
 	// Defaults to 1.
 	CpuCount int `mapstructure:"cpus" required:"false"`
+	// The number of virtual CPU sockets. Together with
+	// `cpu_cores_per_socket` and `cpu_threads_per_core` this describes the
+	// CPU topology of the VM, and CPU hotplug is disabled when it is set. The
+	// product of the three values must be equal to `cpus`; any of them that
+	// is left unset defaults to 1.
+	CPUSockets int `mapstructure:"cpu_sockets" required:"false"`
+	// The number of cores per virtual CPU socket. See `cpu_sockets`.
+	CPUCoresPerSocket int `mapstructure:"cpu_cores_per_socket" required:"false"`
+	// The number of threads per core. See `cpu_sockets`.
+	CPUThreadsPerCore int `mapstructure:"cpu_threads_per_core" required:"false"`
 	// The amount of memory to use for building the VM in
 	// megabytes. Defaults to 512 megabytes.
 	MemorySize int `mapstructure:"memory" required:"false"`
+	// The type of the network adapter of the VM. Valid options are "virtio",
+	// "e1000", "e1000e", and "rtl". By default the adapter type chosen by
+	// Parallels Desktop for the guest OS is used.
+	NICType string `mapstructure:"nic_type" required:"false"`
 	// Specifies whether to enable the sound device when
 	// building the VM. Defaults to false.
 	Sound bool `mapstructure:"sound" required:"false"`
 	// Specifies whether to enable the USB bus when building
 	// the VM. Defaults to false.
 	USB bool `mapstructure:"usb" required:"false"`
+	// The amount of video memory of the VM in megabytes. By default the
+	// amount chosen by Parallels Desktop for the guest OS is used.
+	VideoMemory int `mapstructure:"video_memory" required:"false"`
 }
 
 func (c *HWConfig) Prepare(ctx *interpolate.Context) []error {
@@ -37,6 +54,28 @@ func (c *HWConfig) Prepare(ctx *interpolate.Context) []error {
 		c.CpuCount = 1
 	}
 
+	if c.CPUSockets < 0 || c.CPUCoresPerSocket < 0 || c.CPUThreadsPerCore < 0 {
+		errs = append(errs, fmt.Errorf("An invalid CPU topology was specified (cpu_sockets, cpu_cores_per_socket and cpu_threads_per_core must be >= 0)"))
+	} else if c.HasCPUTopology() {
+		if c.CPUSockets == 0 {
+			c.CPUSockets = 1
+		}
+		if c.CPUCoresPerSocket == 0 {
+			c.CPUCoresPerSocket = 1
+		}
+		if c.CPUThreadsPerCore == 0 {
+			c.CPUThreadsPerCore = 1
+		}
+
+		total := c.CPUSockets * c.CPUCoresPerSocket * c.CPUThreadsPerCore
+		if total != c.CpuCount {
+			errs = append(errs, fmt.Errorf(
+				"The CPU topology does not match the number of cpus: "+
+					"cpu_sockets * cpu_cores_per_socket * cpu_threads_per_core = %d, cpus = %d",
+				total, c.CpuCount))
+		}
+	}
+
 	if c.MemorySize < 0 {
 		errs = append(errs, fmt.Errorf("An invalid memory size was specified (memory < 0): %d", c.MemorySize))
 	}
@@ -44,6 +83,16 @@ func (c *HWConfig) Prepare(ctx *interpolate.Context) []error {
 		c.MemorySize = 512
 	}
 
+	if c.VideoMemory < 0 {
+		errs = append(errs, fmt.Errorf("An invalid video memory size was specified (video_memory < 0): %d", c.VideoMemory))
+	}
+
+	switch c.NICType {
+	case "", "virtio", "e1000", "e1000e", "rtl":
+	default:
+		errs = append(errs, fmt.Errorf("nic_type can only be virtio, e1000, e1000e, or rtl"))
+	}
+
 	// Peripherals
 	if !c.Sound {
 		c.Sound = false
@@ -55,3 +104,8 @@ func (c *HWConfig) Prepare(ctx *interpolate.Context) []error {
 
 	return errs
 }
+
+// HasCPUTopology returns true if any of the CPU topology options is set.
+func (c *HWConfig) HasCPUTopology() bool {
+	return c.CPUSockets > 0 || c.CPUCoresPerSocket > 0 || c.CPUThreadsPerCore > 0
+}
@@ -23,3 +23,64 @@ func TestHWConfigPrepare(t *testing.T) {
 		t.Errorf("bad memory size: %d", c.MemorySize)
 	}
 }
+
+func TestHWConfigPrepare_CPUTopology(t *testing.T) {
+	// Good
+	c := &HWConfig{
+		CpuCount:          8,
+		CPUSockets:        2,
+		CPUCoresPerSocket: 4,
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.CPUThreadsPerCore != 1 {
+		t.Errorf("bad threads per core: %d", c.CPUThreadsPerCore)
+	}
+
+	// Bad: topology does not match cpus
+	c = &HWConfig{
+		CpuCount:          4,
+		CPUSockets:        2,
+		CPUCoresPerSocket: 4,
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 1 {
+		t.Fatalf("should have error: %#v", errs)
+	}
+
+	// Bad: negative value
+	c = &HWConfig{
+		CPUSockets: -1,
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 1 {
+		t.Fatalf("should have error: %#v", errs)
+	}
+}
+
+func TestHWConfigPrepare_NICType(t *testing.T) {
+	// Good
+	c := &HWConfig{NICType: "virtio"}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Bad
+	c = &HWConfig{NICType: "pcnet"}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 1 {
+		t.Fatalf("should have error: %#v", errs)
+	}
+}
+
+func TestHWConfigPrepare_VideoMemory(t *testing.T) {
+	// Good
+	c := &HWConfig{VideoMemory: 256}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Bad
+	c = &HWConfig{VideoMemory: -1}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 1 {
+		t.Fatalf("should have error: %#v", errs)
+	}
+}
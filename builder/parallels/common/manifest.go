@@ -0,0 +1,228 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// Manifest describes a finished build. It is written to manifest.json in the
+// output directory and exposed as the "manifest" artifact state.
+type Manifest struct {
+	ParallelsVersion string         `json:"parallels_version"`
+	ISOChecksum      string         `json:"iso_checksum,omitempty"`
+	Steps            []ManifestStep `json:"steps"`
+	Disks            []ManifestDisk `json:"disks"`
+	Files            []ManifestFile `json:"files"`
+}
+
+// ManifestStep is the time a single build step took.
+type ManifestStep struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// ManifestDisk is a virtual disk of the resulting VM.
+type ManifestDisk struct {
+	Path string `json:"path"`
+	Size uint64 `json:"size_mb"`
+}
+
+// ManifestFile is a file of the resulting VM.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// WriteManifest writes manifest.json into the given output directory,
+// describing the Parallels Desktop version, the step durations recorded by
+// TimeSteps in the given state, the virtual size of the disks and the size
+// and checksum of every file of the resulting VM. It is called once the
+// build steps and their cleanups have run, so that the checksums match the
+// files of the artifact.
+func WriteManifest(driver Driver, outputDir, isoChecksum string, state multistep.StateBag) (*Manifest, error) {
+	manifest := &Manifest{
+		ISOChecksum: isoChecksum,
+		Steps:       []ManifestStep{},
+		Disks:       []ManifestDisk{},
+		Files:       []ManifestFile{},
+	}
+
+	version, err := driver.Version()
+	if err != nil {
+		return nil, fmt.Errorf("Error reading Parallels Desktop version: %s", err)
+	}
+	manifest.ParallelsVersion = version
+
+	if raw, ok := state.GetOk("step_durations"); ok {
+		manifest.Steps = raw.([]ManifestStep)
+	}
+
+	disks, err := vmDisks(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the virtual disk sizes: %s", err)
+	}
+	manifest.Disks = append(manifest.Disks, disks...)
+
+	manifestPath := filepath.Join(outputDir, "manifest.json")
+	err = filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Skip the files that are removed from the artifact
+		for _, unnecessaryFile := range unnecessaryFiles {
+			if unnecessary, _ := regexp.MatchString(unnecessaryFile, path); unnecessary {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if info.IsDir() || path == manifestPath {
+			return nil
+		}
+
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
+		}
+
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:   filepath.ToSlash(rel),
+			Size:   info.Size(),
+			SHA256: sum,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error computing file checksums: %s", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.WriteFile(manifestPath, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error writing manifest: %s", err)
+	}
+
+	return manifest, nil
+}
+
+// TimeSteps wraps the given steps so that the duration of each of them is
+// recorded in the "step_durations" state for WriteManifest. The wrapped steps
+// report the names of the original steps to the debug runner.
+//
+// With -on-error=run-cleanup-provisioner, Packer identifies the provisioning
+// step by its type, so it is not wrapped and its duration is not recorded.
+func TimeSteps(steps []multistep.Step, onError string) []multistep.Step {
+	timed := make([]multistep.Step, len(steps))
+	for i, step := range steps {
+		if _, ok := step.(*commonsteps.StepProvision); ok && onError == "run-cleanup-provisioner" {
+			timed[i] = step
+			continue
+		}
+		timed[i] = &timedStep{step: step}
+	}
+	return timed
+}
+
+type timedStep struct {
+	step multistep.Step
+}
+
+// InnerStepName returns the name of the wrapped step, which is shown by the
+// debug runner when pausing between steps.
+func (s *timedStep) InnerStepName() string {
+	return reflect.Indirect(reflect.ValueOf(s.step)).Type().Name()
+}
+
+func (s *timedStep) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	start := time.Now()
+	action := s.step.Run(ctx, state)
+
+	var durations []ManifestStep
+	if raw, ok := state.GetOk("step_durations"); ok {
+		durations = raw.([]ManifestStep)
+	}
+	state.Put("step_durations", append(durations, ManifestStep{
+		Name:     s.InnerStepName(),
+		Duration: time.Since(start).Seconds(),
+	}))
+
+	return action
+}
+
+func (s *timedStep) Cleanup(state multistep.StateBag) {
+	s.step.Cleanup(state)
+}
+
+// vmDisks returns the virtual disks of the VMs in the given directory, as
+// listed in their config.pvs files.
+func vmDisks(dir string) ([]ManifestDisk, error) {
+	configs, err := filepath.Glob(filepath.Join(dir, "*", "config.pvs"))
+	if err != nil {
+		return nil, err
+	}
+
+	var disks []ManifestDisk
+	for _, config := range configs {
+		data, err := os.ReadFile(config)
+		if err != nil {
+			return nil, err
+		}
+
+		var vm struct {
+			Hdds []struct {
+				Size       uint64 `xml:"Size"`
+				SystemName string `xml:"SystemName"`
+			} `xml:"Hardware>Hdd"`
+		}
+		if err := xml.Unmarshal(data, &vm); err != nil {
+			return nil, fmt.Errorf("%s: %s", config, err)
+		}
+
+		vmDir := filepath.Base(filepath.Dir(config))
+		for _, hdd := range vm.Hdds {
+			disks = append(disks, ManifestDisk{
+				Path: vmDir + "/" + filepath.Base(hdd.SystemName),
+				Size: hdd.Size,
+			})
+		}
+	}
+	return disks, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
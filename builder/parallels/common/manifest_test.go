@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// stepRewriteFile writes to a file on cleanup, like the steps which
+// reconfigure the VM once the build is done.
+type stepRewriteFile struct {
+	path string
+}
+
+func (s *stepRewriteFile) Run(context.Context, multistep.StateBag) multistep.StepAction {
+	return multistep.ActionContinue
+}
+
+func (s *stepRewriteFile) Cleanup(multistep.StateBag) {
+	os.WriteFile(s.path, []byte("bar"), 0644)
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "foo.pvm"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "foo.pvm", "disk.hdd"), []byte("foo"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "foo.pvm", "parallels.log"), []byte("log"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	config := "<ParallelsVirtualMachine><Hardware>" +
+		"<Hdd id=\"0\"><Size>65536</Size><SystemName>disk.hdd</SystemName></Hdd>" +
+		"</Hardware></ParallelsVirtualMachine>"
+	if err := os.WriteFile(filepath.Join(dir, "foo.pvm", "config.pvs"), []byte(config), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state := testState(t)
+	driver := state.Get("driver").(*DriverMock)
+	driver.VersionResult = "18.0.0"
+
+	runner := &multistep.BasicRunner{Steps: TimeSteps([]multistep.Step{
+		new(StepCreateSnapshot),
+		&stepRewriteFile{path: filepath.Join(dir, "foo.pvm", "disk.hdd")},
+	}, "")}
+	runner.Run(context.Background(), state)
+
+	result, err := WriteManifest(driver, dir, "sha256:abc", state)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.ParallelsVersion != "18.0.0" {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if manifest.ParallelsVersion != "18.0.0" || manifest.ISOChecksum != "sha256:abc" {
+		t.Fatalf("bad: %#v", manifest)
+	}
+	if len(manifest.Steps) != 2 || manifest.Steps[0].Name != "StepCreateSnapshot" {
+		t.Fatalf("bad steps: %#v", manifest.Steps)
+	}
+	expectedDisks := []ManifestDisk{{Path: "foo.pvm/disk.hdd", Size: 65536}}
+	if !reflect.DeepEqual(manifest.Disks, expectedDisks) {
+		t.Fatalf("bad disks: %#v", manifest.Disks)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("bad files: %#v", manifest.Files)
+	}
+
+	// The checksum matches the file as rewritten by the cleanup
+	file := manifest.Files[1]
+	if file.Path != "foo.pvm/disk.hdd" || file.Size != 3 ||
+		file.SHA256 != "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9" {
+		t.Fatalf("bad file: %#v", file)
+	}
+}
+
+func TestTimeSteps_stepNames(t *testing.T) {
+	steps := TimeSteps([]multistep.Step{
+		new(StepCreateSnapshot),
+		new(commonsteps.StepProvision),
+	}, "")
+
+	// The debug runner shows the names of the original steps
+	for i, expected := range []string{"StepCreateSnapshot", "StepProvision"} {
+		if name := steps[i].(multistep.StepWrapper).InnerStepName(); name != expected {
+			t.Fatalf("bad: %s", name)
+		}
+	}
+
+	// Packer identifies the provisioning step by its type when it runs the
+	// cleanup provisioner
+	steps = TimeSteps([]multistep.Step{
+		new(commonsteps.StepProvision),
+	}, "run-cleanup-provisioner")
+	if _, ok := steps[0].(*commonsteps.StepProvision); !ok {
+		t.Fatalf("bad: %#v", steps[0])
+	}
+}
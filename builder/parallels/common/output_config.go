@@ -23,6 +23,20 @@ type OutputConfig struct {
 	// the builder. By default this is "output-BUILDNAME" where "BUILDNAME" is the
 	// name of the build.
 	OutputDir string `mapstructure:"output_directory" required:"false"`
+	// Leave the VM registered in Parallels Desktop after a successful build
+	// instead of unregistering it, so it can be started and inspected
+	// manually. The artifact then refers to the registered VM, and destroying
+	// the artifact unregisters it. Defaults to `false`.
+	KeepRegistered bool `mapstructure:"keep_registered" required:"false"`
+	// Write a `manifest.json` file into the output directory once the build
+	// has finished. It contains the Parallels Desktop version, the ISO
+	// checksum, the duration of every build step, the virtual size in
+	// megabytes of every disk and the size and SHA256 checksum of every file
+	// of the resulting VM. With `-on-error=run-cleanup-provisioner` the
+	// duration of the provisioning step is not recorded. The manifest is also
+	// available to post-processors as the `manifest` artifact state. Defaults
+	// to `false`, since checksumming large disk images takes a while.
+	OutputManifest bool `mapstructure:"output_manifest" required:"false"`
 }
 
 // Prepare configures the output directory or returns an error if it already exists.
@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"fmt"
+	"net"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// RunConfig contains the configuration for how the VM is started.
+type RunConfig struct {
+	// Packer defaults to building Parallels virtual machines by launching a
+	// GUI that shows the console of the machine being built. When this value
+	// is set to `true`, the machine will start without a console. The console
+	// can still be viewed over VNC, see `vnc_bind_address`. Defaults to
+	// `false`.
+	Headless bool `mapstructure:"headless" required:"false"`
+	// The IP address that the VNC server of a headless VM should be bound to.
+	// Addresses other than loopback addresses require a `vnc_password`.
+	// Defaults to "127.0.0.1".
+	VNCBindAddress string `mapstructure:"vnc_bind_address" required:"false"`
+	// The password of the VNC server of a headless VM. By default the VNC
+	// server has no password.
+	VNCPassword string `mapstructure:"vnc_password" required:"false"`
+	// The minimum port to use for the VNC server of a headless VM. Packer
+	// picks a random free port between `vnc_port_min` and `vnc_port_max`.
+	// Defaults to 5900.
+	VNCPortMin int `mapstructure:"vnc_port_min" required:"false"`
+	// The maximum port to use for the VNC server of a headless VM. Defaults
+	// to 6000.
+	VNCPortMax int `mapstructure:"vnc_port_max" required:"false"`
+}
+
+// Prepare sets the default values for the run configuration and validates
+// the VNC settings.
+func (c *RunConfig) Prepare(ctx *interpolate.Context) []error {
+	if c.VNCBindAddress == "" {
+		c.VNCBindAddress = "127.0.0.1"
+	}
+
+	if c.VNCPortMin == 0 {
+		c.VNCPortMin = 5900
+	}
+
+	if c.VNCPortMax == 0 {
+		c.VNCPortMax = 6000
+	}
+
+	var errs []error
+	if c.VNCPortMin > c.VNCPortMax {
+		errs = append(errs,
+			fmt.Errorf("vnc_port_min must be less than vnc_port_max"))
+	}
+
+	if c.Headless && c.VNCPassword == "" {
+		if ip := net.ParseIP(c.VNCBindAddress); ip == nil || !ip.IsLoopback() {
+			errs = append(errs,
+				fmt.Errorf("vnc_password must be set when vnc_bind_address is not a loopback address"))
+		}
+	}
+
+	if c.VNCPassword != "" {
+		packersdk.LogSecretFilter.Set(c.VNCPassword)
+	}
+
+	return errs
+}
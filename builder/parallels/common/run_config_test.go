@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestRunConfigPrepare(t *testing.T) {
+	c := new(RunConfig)
+	errs := c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	if c.VNCBindAddress != "127.0.0.1" {
+		t.Errorf("bad vnc bind address: %s", c.VNCBindAddress)
+	}
+	if c.VNCPortMin != 5900 || c.VNCPortMax != 6000 {
+		t.Errorf("bad vnc port range: %d-%d", c.VNCPortMin, c.VNCPortMax)
+	}
+}
+
+func TestRunConfigPrepare_VNCPortRange(t *testing.T) {
+	c := &RunConfig{
+		VNCPortMin: 6000,
+		VNCPortMax: 5900,
+	}
+	errs := c.Prepare(interpolate.NewContext())
+	if len(errs) != 1 {
+		t.Fatalf("should have error: %#v", errs)
+	}
+}
+
+func TestRunConfigPrepare_VNCBindAddress(t *testing.T) {
+	// A loopback address doesn't need a password
+	c := &RunConfig{
+		Headless:       true,
+		VNCBindAddress: "127.0.0.1",
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Other addresses do
+	c = &RunConfig{
+		Headless:       true,
+		VNCBindAddress: "0.0.0.0",
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 1 {
+		t.Fatalf("should have error: %#v", errs)
+	}
+
+	c = &RunConfig{
+		Headless:       true,
+		VNCBindAddress: "0.0.0.0",
+		VNCPassword:    "secret",
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// VNC is only used by headless VMs
+	c = &RunConfig{
+		VNCBindAddress: "0.0.0.0",
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type SharedFolder
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// SharedFolder is a host directory that is shared with the guest during the
+// build.
+type SharedFolder struct {
+	// The name of the shared folder, as seen by the guest.
+	Name string `mapstructure:"name" required:"true"`
+	// The path to the directory on the host to share.
+	HostPath string `mapstructure:"host_path" required:"true"`
+	// Allow the guest to write to the shared folder. Defaults to `false`.
+	Writable bool `mapstructure:"writable" required:"false"`
+}
+
+// SharedFolderConfig contains the configuration of the folders shared with
+// the guest during the build.
+type SharedFolderConfig struct {
+	// Host directories to share with the guest during the build, for example
+	// to copy large payloads faster than over the communicator. The shared
+	// folders are removed from the VM before the build finishes, so they are
+	// not part of the resulting VM, and host sharing is restored to the state
+	// it had before the build. See [Shared Folders](#shared-folders).
+	SharedFolders []SharedFolder `mapstructure:"shared_folders" required:"false"`
+}
+
+// Prepare validates the shared folders.
+func (c *SharedFolderConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	names := make(map[string]bool)
+	for i, f := range c.SharedFolders {
+		if f.Name == "" {
+			errs = append(errs, fmt.Errorf("shared_folders[%d]: name must be specified", i))
+		} else if names[f.Name] {
+			errs = append(errs, fmt.Errorf("shared_folders[%d]: duplicate name %q", i, f.Name))
+		}
+		names[f.Name] = true
+
+		if f.HostPath == "" {
+			errs = append(errs, fmt.Errorf("shared_folders[%d]: host_path must be specified", i))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestSharedFolderConfigPrepare(t *testing.T) {
+	c := &SharedFolderConfig{
+		SharedFolders: []SharedFolder{
+			{Name: "payload", HostPath: "/tmp/payload"},
+			{Name: "results", HostPath: "/tmp/results", Writable: true},
+		},
+	}
+	errs := c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+}
+
+func TestSharedFolderConfigPrepare_Invalid(t *testing.T) {
+	c := &SharedFolderConfig{
+		SharedFolders: []SharedFolder{
+			{Name: "payload"},
+			{Name: "payload", HostPath: "/tmp/payload"},
+			{HostPath: "/tmp/results"},
+		},
+	}
+	errs := c.Prepare(interpolate.NewContext())
+	if len(errs) != 3 {
+		t.Fatalf("should have errors: %#v", errs)
+	}
+}
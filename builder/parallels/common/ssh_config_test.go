@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
@@ -32,6 +33,23 @@ func TestSSHConfigPrepare(t *testing.T) {
 	if c.Comm.SSHPort != 22 {
 		t.Errorf("bad ssh port: %d", c.Comm.SSHPort)
 	}
+
+	if c.Comm.SSHKeepAliveInterval != 5*time.Second {
+		t.Errorf("bad ssh keep alive interval: %s", c.Comm.SSHKeepAliveInterval)
+	}
+}
+
+func TestSSHConfigPrepare_SSHKeepAliveInterval(t *testing.T) {
+	c := testSSHConfig()
+	c.Comm.SSHKeepAliveInterval = 30 * time.Second
+	errs := c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	if c.Comm.SSHKeepAliveInterval != 30*time.Second {
+		t.Errorf("bad ssh keep alive interval: %s", c.Comm.SSHKeepAliveInterval)
+	}
 }
 
 func TestSSHConfigPrepare_SSHPrivateKey(t *testing.T) {
@@ -21,9 +21,9 @@ import (
 //	vmName string
 //
 // Produces:
-type StepAttachFloppy struct {
-	floppyPath string
-}
+//
+//	attachedFloppy bool
+type StepAttachFloppy struct{}
 
 // Run adds a virtual FDD device to the VM and attaches the image.
 // If the image is not specified, then this step will be skipped.
@@ -63,21 +63,22 @@ func (s *StepAttachFloppy) Run(ctx context.Context, state multistep.StateBag) mu
 		return multistep.ActionHalt
 	}
 
-	// Track the path so that we can unregister it from Parallels later
-	s.floppyPath = floppyPath
+	// Set some state so we know to remove
+	state.Put("attachedFloppy", true)
 
 	return multistep.ActionContinue
 }
 
-// Cleanup removes the virtual FDD device attached to the VM.
+// Cleanup removes the virtual FDD device attached to the VM, unless
+// StepDetachMedia has removed it already.
 func (s *StepAttachFloppy) Cleanup(state multistep.StateBag) {
-	driver := state.Get("driver").(Driver)
-	vmName := state.Get("vmName").(string)
-
-	if s.floppyPath == "" {
+	if _, ok := state.GetOk("attachedFloppy"); !ok {
 		return
 	}
 
+	driver := state.Get("driver").(Driver)
+	vmName := state.Get("vmName").(string)
+
 	log.Println("Detaching floppy disk...")
 	command := []string{
 		"set", vmName,
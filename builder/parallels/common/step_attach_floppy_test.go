@@ -67,6 +67,15 @@ func TestStepAttachFloppy(t *testing.T) {
 	if driver.PrlctlCalls[1][6] != "--connect" {
 		t.Fatal("bad call")
 	}
+	if _, ok := state.GetOk("attachedFloppy"); !ok {
+		t.Fatal("should mark the floppy as attached")
+	}
+
+	// Test the cleanup
+	step.Cleanup(state)
+	if len(driver.PrlctlCalls) != 3 || driver.PrlctlCalls[2][3] != "fdd0" {
+		t.Fatalf("bad calls: %#v", driver.PrlctlCalls)
+	}
 }
 
 func TestStepAttachFloppy_noFloppy(t *testing.T) {
@@ -23,8 +23,9 @@ import (
 //	vmName string
 //
 // Produces:
+//
+//	attachedParallelsTools string - The name of the CD/DVD drive.
 type StepAttachParallelsTools struct {
-	cdromDevice        string
 	ParallelsToolsMode string
 }
 
@@ -57,14 +58,16 @@ func (s *StepAttachParallelsTools) Run(ctx context.Context, state multistep.Stat
 	}
 
 	// Track the device name so that we can can delete later
-	s.cdromDevice = cdrom
+	state.Put("attachedParallelsTools", cdrom)
 
 	return multistep.ActionContinue
 }
 
-// Cleanup removes the virtual CD-ROM device attached to the VM.
+// Cleanup removes the virtual CD-ROM device attached to the VM, unless
+// StepDetachMedia has removed it already.
 func (s *StepAttachParallelsTools) Cleanup(state multistep.StateBag) {
-	if s.cdromDevice == "" {
+	cdromDevice, ok := state.GetOk("attachedParallelsTools")
+	if !ok {
 		return
 	}
 
@@ -76,7 +79,7 @@ func (s *StepAttachParallelsTools) Cleanup(state multistep.StateBag) {
 
 	command := []string{
 		"set", vmName,
-		"--device-del", s.cdromDevice,
+		"--device-del", cdromDevice.(string),
 	}
 
 	if err := driver.Prlctl(command...); err != nil {
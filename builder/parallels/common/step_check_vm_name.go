@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepCheckVMName is a step that makes sure no VM with the configured name is
+// already registered in Parallels Desktop, so that concurrent or leftover
+// builds fail early instead of colliding halfway through.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//
+// Produces:
+//
+//	<nothing>
+type StepCheckVMName struct {
+	VMName string
+}
+
+// Run checks the list of registered VMs for the configured name.
+func (s *StepCheckVMName) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	registered, err := driver.IsRegistered(s.VMName)
+	if err != nil {
+		err = fmt.Errorf("Error checking registered virtual machines: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if registered {
+		err := fmt.Errorf(
+			"A virtual machine named %q is already registered. Remove it or "+
+				"set a different vm_name.", s.VMName)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepCheckVMName) Cleanup(multistep.StateBag) {}
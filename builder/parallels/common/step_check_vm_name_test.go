@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepCheckVMName_impl(t *testing.T) {
+	var _ multistep.Step = new(StepCheckVMName)
+}
+
+func TestStepCheckVMName(t *testing.T) {
+	state := testState(t)
+	step := &StepCheckVMName{VMName: "foo"}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+	if driver.IsRegisteredName != "foo" {
+		t.Fatalf("bad: %s", driver.IsRegisteredName)
+	}
+}
+
+func TestStepCheckVMName_registered(t *testing.T) {
+	state := testState(t)
+	step := &StepCheckVMName{VMName: "foo"}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IsRegisteredReturn = true
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
+
+func TestStepCheckVMName_error(t *testing.T) {
+	state := testState(t)
+	step := &StepCheckVMName{VMName: "foo"}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IsRegisteredErr = errors.New("prlctl failed")
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/net"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepConfigureHeadless is a step that configures the VM to start without a
+// console window and enables its VNC server on a free port, so the console
+// can still be viewed remotely. If headless mode is disabled, this step will
+// be skipped.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	startup_view string - The startup view of the VM before it was changed.
+//	vnc_ip string - The IP address the VNC server is bound to.
+//	vnc_port int - The port of the VNC server.
+type StepConfigureHeadless struct {
+	Headless       bool
+	VNCBindAddress string
+	VNCPassword    string
+	VNCPortMin     int
+	VNCPortMax     int
+}
+
+// Run sets the startup view of the VM to headless and enables VNC.
+func (s *StepConfigureHeadless) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Headless {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say("Configuring the virtual machine to run headless...")
+	l, err := net.ListenRangeConfig{
+		Addr:    s.VNCBindAddress,
+		Min:     s.VNCPortMin,
+		Max:     s.VNCPortMax,
+		Network: "tcp",
+	}.Listen(ctx)
+	if err != nil {
+		err = fmt.Errorf("Error finding a free VNC port: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	// Release the port so that Parallels Desktop can bind to it
+	vncPort := l.Port
+	l.Close()
+
+	startupView, err := driver.StartupView(vmName)
+	if err != nil {
+		log.Printf("Could not determine the startup view, assuming \"same\": %s", err)
+		startupView = "same"
+	}
+
+	command := []string{
+		"set", vmName,
+		"--startup-view", "headless",
+		"--vnc-mode", "manual",
+		"--vnc-port", strconv.Itoa(vncPort),
+		"--vnc-address", s.VNCBindAddress,
+	}
+	if s.VNCPassword != "" {
+		command = append(command, "--vnc-passwd", s.VNCPassword)
+	} else {
+		command = append(command, "--vnc-nopasswd")
+	}
+	if err := driver.Prlctl(command...); err != nil {
+		err = fmt.Errorf("Error configuring headless mode: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say(fmt.Sprintf("The VM console is available over VNC at %s:%d", s.VNCBindAddress, vncPort))
+	state.Put("startup_view", startupView)
+	state.Put("vnc_ip", s.VNCBindAddress)
+	state.Put("vnc_port", vncPort)
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepConfigureHeadless) Cleanup(multistep.StateBag) {}
+
+// StepRestoreHeadless is a step that disables the VNC server of the VM and
+// restores the startup view it had before StepConfigureHeadless ran, so the
+// exported VM doesn't keep a VNC server open. If headless mode wasn't
+// configured, this step will be skipped.
+//
+// Uses:
+//
+//	driver Driver
+//	startup_view string
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	<nothing>
+type StepRestoreHeadless struct{}
+
+// Run disables VNC and restores the startup view of the VM.
+func (s *StepRestoreHeadless) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	startupView, ok := state.GetOk("startup_view")
+	if !ok {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say("Disabling VNC and restoring the startup view...")
+	command := []string{
+		"set", vmName,
+		"--startup-view", startupView.(string),
+		"--vnc-mode", "off",
+	}
+	if err := driver.Prlctl(command...); err != nil {
+		err = fmt.Errorf("Error disabling VNC: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Remove("startup_view")
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepRestoreHeadless) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,177 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepConfigureHeadless_impl(t *testing.T) {
+	var _ multistep.Step = new(StepConfigureHeadless)
+}
+
+func TestStepConfigureHeadless(t *testing.T) {
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepConfigureHeadless{
+		Headless:       true,
+		VNCBindAddress: "127.0.0.1",
+		VNCPortMin:     5900,
+		VNCPortMax:     6000,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.StartupViewResult = "window"
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	if view := state.Get("startup_view").(string); view != "window" {
+		t.Fatalf("bad startup view: %s", view)
+	}
+
+	port := state.Get("vnc_port").(int)
+	if port < 5900 || port >= 6000 {
+		t.Fatalf("bad port: %d", port)
+	}
+
+	if len(driver.PrlctlCalls) != 1 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+	call := driver.PrlctlCalls[0]
+	if call[3] != "headless" || call[7] != strconv.Itoa(port) {
+		t.Fatalf("bad: %#v", call)
+	}
+	if call[len(call)-1] != "--vnc-nopasswd" {
+		t.Fatalf("bad: %#v", call)
+	}
+}
+
+func TestStepConfigureHeadless_password(t *testing.T) {
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepConfigureHeadless{
+		Headless:       true,
+		VNCBindAddress: "127.0.0.1",
+		VNCPassword:    "secret",
+		VNCPortMin:     5900,
+		VNCPortMax:     6000,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.StartupViewErr = errors.New("test error")
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// The startup view falls back to the default of new VMs
+	if view := state.Get("startup_view").(string); view != "same" {
+		t.Fatalf("bad startup view: %s", view)
+	}
+
+	call := driver.PrlctlCalls[0]
+	expected := []string{"--vnc-passwd", "secret"}
+	if !reflect.DeepEqual(call[len(call)-2:], expected) {
+		t.Fatalf("bad: %#v", call)
+	}
+}
+
+func TestStepConfigureHeadless_skip(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := new(StepConfigureHeadless)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRestoreHeadless_impl(t *testing.T) {
+	var _ multistep.Step = new(StepRestoreHeadless)
+}
+
+func TestStepRestoreHeadless(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	state.Put("startup_view", "window")
+	step := new(StepRestoreHeadless)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Test the driver
+	expected := [][]string{
+		{"set", "foo", "--startup-view", "window", "--vnc-mode", "off"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRestoreHeadless_skip(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := new(StepRestoreHeadless)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRestoreHeadless_error(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	state.Put("startup_view", "window")
+	step := new(StepRestoreHeadless)
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.PrlctlErrs = []error{errors.New("test error")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepCreateSnapshot is a step that takes a named snapshot of the stopped
+// virtual machine, so that the snapshot is part of the resulting PVM. If no
+// snapshot name is configured, this step will be skipped.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	<nothing>
+type StepCreateSnapshot struct {
+	Name string
+}
+
+// Run takes the snapshot.
+func (s *StepCreateSnapshot) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Name == "" {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say(fmt.Sprintf("Creating snapshot %q...", s.Name))
+	if err := driver.Prlctl("snapshot", vmName, "--name", s.Name); err != nil {
+		err = fmt.Errorf("Error creating snapshot: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepCreateSnapshot) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepCreateSnapshot_impl(t *testing.T) {
+	var _ multistep.Step = new(StepCreateSnapshot)
+}
+
+func TestStepCreateSnapshot(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepCreateSnapshot{Name: "clean"}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	expected := [][]string{{"snapshot", "foo", "--name", "clean"}}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepCreateSnapshot_skip(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := new(StepCreateSnapshot)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepCreateSnapshot_error(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepCreateSnapshot{Name: "clean"}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.PrlctlErrs = []error{errors.New("snapshot failed")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepDetachMedia is a step that detaches the installation ISO, the floppy
+// disk and the Parallels Tools ISO from the VM once they are no longer
+// needed, so that the snapshot and the resulting VM don't refer to files on
+// the build host. Media which were not attached are skipped.
+//
+// Uses:
+//
+//	attachedFloppy bool
+//	attachedIso bool
+//	attachedParallelsTools string
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	<nothing>
+type StepDetachMedia struct{}
+
+// Run detaches the media attached by the earlier steps.
+func (s *StepDetachMedia) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	if _, ok := state.GetOk("attachedIso"); ok {
+		ui.Say("Detaching ISO from the default CD/DVD ROM device...")
+		command := []string{
+			"set", vmName,
+			"--device-set", "cdrom0",
+			"--image", "", "--disconnect", "--enable",
+		}
+		if err := driver.Prlctl(command...); err != nil {
+			err = fmt.Errorf("Error detaching ISO: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		state.Remove("attachedIso")
+	}
+
+	if _, ok := state.GetOk("attachedFloppy"); ok {
+		ui.Say("Detaching floppy disk...")
+		if err := driver.Prlctl("set", vmName, "--device-del", "fdd0"); err != nil {
+			err = fmt.Errorf("Error detaching floppy disk: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		state.Remove("attachedFloppy")
+	}
+
+	if device, ok := state.GetOk("attachedParallelsTools"); ok {
+		ui.Say("Detaching Parallels Tools ISO...")
+		if err := driver.Prlctl("set", vmName, "--device-del", device.(string)); err != nil {
+			err = fmt.Errorf("Error detaching Parallels Tools ISO: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		state.Remove("attachedParallelsTools")
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepDetachMedia) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepDetachMedia_impl(t *testing.T) {
+	var _ multistep.Step = new(StepDetachMedia)
+}
+
+func TestStepDetachMedia(t *testing.T) {
+	state := testState(t)
+	step := new(StepDetachMedia)
+
+	state.Put("vmName", "foo")
+	state.Put("attachedIso", true)
+	state.Put("attachedFloppy", true)
+	state.Put("attachedParallelsTools", "cdrom1")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	expected := [][]string{
+		{"set", "foo", "--device-set", "cdrom0", "--image", "", "--disconnect", "--enable"},
+		{"set", "foo", "--device-del", "fdd0"},
+		{"set", "foo", "--device-del", "cdrom1"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad calls: %#v", driver.PrlctlCalls)
+	}
+
+	// The cleanups of the attach steps have nothing left to do
+	for _, key := range []string{"attachedIso", "attachedFloppy", "attachedParallelsTools"} {
+		if _, ok := state.GetOk(key); ok {
+			t.Fatalf("%s should be removed", key)
+		}
+	}
+}
+
+func TestStepDetachMedia_skip(t *testing.T) {
+	state := testState(t)
+	step := new(StepDetachMedia)
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) > 0 {
+		t.Fatal("should not call prlctl")
+	}
+}
+
+func TestStepDetachMedia_error(t *testing.T) {
+	state := testState(t)
+	step := new(StepDetachMedia)
+
+	state.Put("vmName", "foo")
+	state.Put("attachedFloppy", true)
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.PrlctlErrs = []error{errors.New("foo")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+	if _, ok := state.GetOk("attachedFloppy"); !ok {
+		t.Fatal("attachedFloppy should be kept for cleanup")
+	}
+}
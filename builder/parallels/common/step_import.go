@@ -13,11 +13,12 @@ import (
 
 // This step imports an PVM VM into Parallels.
 type StepImport struct {
-	Name        string
-	SourcePath  string
-	vmName      string
-	OutputDir   string
-	ReassignMAC bool
+	Name           string
+	SourcePath     string
+	vmName         string
+	OutputDir      string
+	ReassignMAC    bool
+	KeepRegistered bool
 }
 
 func (s *StepImport) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -46,8 +47,22 @@ func (s *StepImport) Cleanup(state multistep.StateBag) {
 	driver := state.Get("driver").(Driver)
 	ui := state.Get("ui").(packersdk.Ui)
 
+	if KeepRegistered(state, s.KeepRegistered) {
+		ui.Say("Keeping virtual machine registered")
+		return
+	}
+
 	ui.Say("Unregistering virtual machine...")
 	if err := driver.Prlctl("unregister", s.vmName); err != nil {
 		ui.Error(fmt.Sprintf("Error unregistering virtual machine: %s", err))
 	}
 }
+
+// KeepRegistered reports whether the VM should be left registered when the
+// build is cleaned up. This is only the case if keep_registered is set and
+// the build was neither cancelled nor halted by an error.
+func KeepRegistered(state multistep.StateBag, keepRegistered bool) bool {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	return keepRegistered && !cancelled && !halted
+}
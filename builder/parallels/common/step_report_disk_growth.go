@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+)
+
+// StepReportDiskGrowth is a step that measures the size of the output
+// directory and reports how much it has grown since the previous measurement.
+//
+// Uses:
+//
+//	ui packersdk.Ui
+//
+// Produces:
+//
+//	disk_growth_size int64 - The last measured size of the output directory
+type StepReportDiskGrowth struct {
+	Enabled bool
+	Path    string
+	Phase   string
+}
+
+// Run measures the output directory and reports its size.
+func (s *StepReportDiskGrowth) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Enabled {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+
+	size, err := dirSize(s.Path)
+	if err != nil {
+		err = fmt.Errorf("Error measuring output directory size: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	message := fmt.Sprintf("Output directory size %s: %s", s.Phase, formatSize(size))
+	if prevSize, ok := state.GetOk("disk_growth_size"); ok {
+		growth := size - prevSize.(int64)
+		if growth < 0 {
+			message += fmt.Sprintf(" (-%s)", formatSize(-growth))
+		} else {
+			message += fmt.Sprintf(" (+%s)", formatSize(growth))
+		}
+	}
+	ui.Say(message)
+
+	state.Put("disk_growth_size", size)
+
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("disk_growth", size)
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepReportDiskGrowth) Cleanup(multistep.StateBag) {}
+
+// dirSize returns the total size in bytes of all files within the directory.
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// formatSize returns a human-readable representation of the given number of
+// bytes in gigabytes or megabytes.
+func formatSize(bytes int64) string {
+	if bytes >= 1<<30 {
+		return fmt.Sprintf("%.2f GB", float64(bytes)/(1<<30))
+	}
+	return fmt.Sprintf("%.2f MB", float64(bytes)/(1<<20))
+}
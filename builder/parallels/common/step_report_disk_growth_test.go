@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepReportDiskGrowth_impl(t *testing.T) {
+	var _ multistep.Step = new(StepReportDiskGrowth)
+}
+
+func TestStepReportDiskGrowth(t *testing.T) {
+	dir := t.TempDir()
+	state := testState(t)
+	step := &StepReportDiskGrowth{
+		Enabled: true,
+		Path:    dir,
+		Phase:   "before OS installation",
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "disk.hds"), make([]byte, 1024), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	if size := state.Get("disk_growth_size").(int64); size != 1024 {
+		t.Fatalf("bad size: %d", size)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "disk.hds"), make([]byte, 4096), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	step.Phase = "after provisioning"
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	generatedData := state.Get("generated_data").(map[string]interface{})
+	if generatedData["disk_growth"] != int64(4096) {
+		t.Fatalf("bad disk_growth: %#v", generatedData["disk_growth"])
+	}
+}
+
+func TestStepReportDiskGrowth_disabled(t *testing.T) {
+	state := testState(t)
+	step := &StepReportDiskGrowth{
+		Path: "/i/dont/exist",
+	}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+	if _, ok := state.GetOk("generated_data"); ok {
+		t.Fatal("should NOT have generated data")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	cases := map[int64]string{
+		0:                      "0.00 MB",
+		512 * 1024 * 1024:      "512.00 MB",
+		3 * 1024 * 1024 * 1024: "3.00 GB",
+	}
+
+	for bytes, expected := range cases {
+		if actual := formatSize(bytes); actual != expected {
+			t.Errorf("formatSize(%d) = %q, expected %q", bytes, actual, expected)
+		}
+	}
+}
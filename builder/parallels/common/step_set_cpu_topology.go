@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepSetCPUTopology is a step that applies the configured CPU topology
+// (sockets x cores x threads) to the virtual machine. If no topology is
+// configured, this step will be skipped.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	<nothing>
+type StepSetCPUTopology struct {
+	Sockets        int
+	CoresPerSocket int
+	ThreadsPerCore int
+}
+
+// Run sets the total number of CPUs and disables CPU hotplug.
+func (s *StepSetCPUTopology) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Sockets == 0 && s.CoresPerSocket == 0 && s.ThreadsPerCore == 0 {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	total := s.Sockets * s.CoresPerSocket * s.ThreadsPerCore
+
+	ui.Say(fmt.Sprintf("Setting CPU topology: %d socket(s) x %d core(s) x %d thread(s)...",
+		s.Sockets, s.CoresPerSocket, s.ThreadsPerCore))
+	command := []string{
+		"set", vmName,
+		"--cpus", strconv.Itoa(total),
+		"--cpu-hotplug", "off",
+	}
+	if err := driver.Prlctl(command...); err != nil {
+		err = fmt.Errorf("Error setting CPU topology: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepSetCPUTopology) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepSetCPUTopology_impl(t *testing.T) {
+	var _ multistep.Step = new(StepSetCPUTopology)
+}
+
+func TestStepSetCPUTopology(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSetCPUTopology{
+		Sockets:        2,
+		CoresPerSocket: 4,
+		ThreadsPerCore: 1,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	expected := [][]string{{"set", "foo", "--cpus", "8", "--cpu-hotplug", "off"}}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepSetCPUTopology_skip(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := new(StepSetCPUTopology)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
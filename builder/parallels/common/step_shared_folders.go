@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepSharedFolders is a step that shares the configured host directories
+// with the guest. The shared folders are removed again by
+// StepRemoveSharedFolders, or on cleanup if the build fails before that step,
+// and host sharing is disabled again if this step enabled it. If no shared
+// folders are configured, this step will be skipped.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	shared_folders []string - The names of the shared folders added to the VM.
+//	shared_folders_enabled bool - Whether host sharing was enabled by this step.
+type StepSharedFolders struct {
+	SharedFolders []SharedFolder
+}
+
+// Run enables host sharing and adds the shared folders to the VM.
+func (s *StepSharedFolders) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.SharedFolders) == 0 {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say("Adding shared folders...")
+	enabled, err := driver.SharedFoldersEnabled(vmName)
+	if err != nil {
+		err = fmt.Errorf("Error reading the host sharing state: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if !enabled {
+		if err := driver.Prlctl("set", vmName, "--shf-host", "on"); err != nil {
+			err = fmt.Errorf("Error enabling shared folders: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		state.Put("shared_folders_enabled", true)
+	}
+
+	var added []string
+	for _, f := range s.SharedFolders {
+		mode := "ro"
+		if f.Writable {
+			mode = "rw"
+		}
+
+		ui.Message(fmt.Sprintf("Sharing %s as %q (%s)", f.HostPath, f.Name, mode))
+		command := []string{
+			"set", vmName,
+			"--shf-host-add", f.Name,
+			"--path", f.HostPath,
+			"--mode", mode,
+		}
+		if err := driver.Prlctl(command...); err != nil {
+			err = fmt.Errorf("Error adding shared folder %q: %s", f.Name, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		added = append(added, f.Name)
+		state.Put("shared_folders", added)
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup removes the shared folders that are still attached to the VM.
+func (s *StepSharedFolders) Cleanup(state multistep.StateBag) {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	if err := removeSharedFolders(driver, state); err != nil {
+		ui.Error(fmt.Sprintf("Error removing shared folders: %s", err))
+	}
+}
+
+// StepRemoveSharedFolders is a step that removes the shared folders added by
+// StepSharedFolders from the VM, so that they are not part of the resulting
+// VM, and disables host sharing again if StepSharedFolders enabled it. If no
+// shared folders were added, this step will be skipped.
+//
+// Uses:
+//
+//	driver Driver
+//	shared_folders []string
+//	shared_folders_enabled bool
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	<nothing>
+type StepRemoveSharedFolders struct{}
+
+// Run removes the shared folders and restores host sharing.
+func (s *StepRemoveSharedFolders) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	if err := removeSharedFolders(driver, state); err != nil {
+		err = fmt.Errorf("Error removing shared folders: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (*StepRemoveSharedFolders) Cleanup(multistep.StateBag) {}
+
+// removeSharedFolders removes the shared folders added by StepSharedFolders
+// and disables host sharing if that step enabled it. The state is updated as
+// the changes are undone, so that they are not undone twice.
+func removeSharedFolders(driver Driver, state multistep.StateBag) error {
+	names, added := state.GetOk("shared_folders")
+	_, enabled := state.GetOk("shared_folders_enabled")
+	if !added && !enabled {
+		return nil
+	}
+
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say("Removing shared folders...")
+	if added {
+		remaining := names.([]string)
+		for len(remaining) > 0 {
+			if err := driver.Prlctl("set", vmName, "--shf-host-del", remaining[0]); err != nil {
+				return err
+			}
+			remaining = remaining[1:]
+			state.Put("shared_folders", remaining)
+		}
+		state.Remove("shared_folders")
+	}
+
+	if enabled {
+		if err := driver.Prlctl("set", vmName, "--shf-host", "off"); err != nil {
+			return err
+		}
+		state.Remove("shared_folders_enabled")
+	}
+
+	return nil
+}
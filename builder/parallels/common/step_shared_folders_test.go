@@ -0,0 +1,212 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepSharedFolders_impl(t *testing.T) {
+	var _ multistep.Step = new(StepSharedFolders)
+}
+
+func TestStepSharedFolders(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSharedFolders{
+		SharedFolders: []SharedFolder{
+			{Name: "payload", HostPath: "/tmp/payload"},
+			{Name: "results", HostPath: "/tmp/results", Writable: true},
+		},
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	expected := [][]string{
+		{"set", "foo", "--shf-host", "on"},
+		{"set", "foo", "--shf-host-add", "payload", "--path", "/tmp/payload", "--mode", "ro"},
+		{"set", "foo", "--shf-host-add", "results", "--path", "/tmp/results", "--mode", "rw"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+
+	// Test the removal
+	driver.PrlctlCalls = nil
+	remove := new(StepRemoveSharedFolders)
+	if action := remove.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	expected = [][]string{
+		{"set", "foo", "--shf-host-del", "payload"},
+		{"set", "foo", "--shf-host-del", "results"},
+		{"set", "foo", "--shf-host", "off"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+
+	// The folders are already removed, so the cleanup does nothing
+	driver.PrlctlCalls = nil
+	step.Cleanup(state)
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepSharedFolders_cleanup(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSharedFolders{
+		SharedFolders: []SharedFolder{
+			{Name: "payload", HostPath: "/tmp/payload"},
+		},
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	// Test the cleanup
+	driver.PrlctlCalls = nil
+	step.Cleanup(state)
+
+	expected := [][]string{
+		{"set", "foo", "--shf-host-del", "payload"},
+		{"set", "foo", "--shf-host", "off"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepSharedFolders_skip(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := new(StepSharedFolders)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	step.Cleanup(state)
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepSharedFolders_error(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSharedFolders{
+		SharedFolders: []SharedFolder{
+			{Name: "payload", HostPath: "/tmp/payload"},
+		},
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.PrlctlErrs = []error{nil, errors.New("no such directory")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+
+	// Host sharing is disabled again, although no folder was added
+	driver.PrlctlCalls = nil
+	driver.PrlctlErrs = nil
+	step.Cleanup(state)
+
+	expected := [][]string{
+		{"set", "foo", "--shf-host", "off"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepSharedFolders_alreadyEnabled(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSharedFolders{
+		SharedFolders: []SharedFolder{
+			{Name: "payload", HostPath: "/tmp/payload"},
+		},
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.SharedFoldersEnabledResult = true
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if driver.SharedFoldersEnabledName != "foo" {
+		t.Fatalf("bad: %s", driver.SharedFoldersEnabledName)
+	}
+
+	expected := [][]string{
+		{"set", "foo", "--shf-host-add", "payload", "--path", "/tmp/payload", "--mode", "ro"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+
+	// Host sharing of the source VM is left enabled
+	driver.PrlctlCalls = nil
+	remove := new(StepRemoveSharedFolders)
+	if action := remove.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	expected = [][]string{
+		{"set", "foo", "--shf-host-del", "payload"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepSharedFolders_stateError(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSharedFolders{
+		SharedFolders: []SharedFolder{
+			{Name: "payload", HostPath: "/tmp/payload"},
+		},
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.SharedFoldersEnabledErr = errors.New("foo")
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
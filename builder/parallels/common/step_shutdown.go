@@ -6,7 +6,6 @@ package common
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -16,7 +15,8 @@ import (
 )
 
 // StepShutdown is a step that shuts down the machine. It first attempts to do
-// so gracefully, but ultimately forcefully shuts it down if that fails.
+// so gracefully, but ultimately forcefully shuts it down if that fails or
+// times out.
 //
 // Uses:
 //
@@ -70,10 +70,15 @@ func (s *StepShutdown) Run(ctx context.Context, state multistep.StateBag) multis
 			case <-shutdownTimer:
 				log.Printf("Shutdown stdout: %s", stdout.String())
 				log.Printf("Shutdown stderr: %s", stderr.String())
-				err := errors.New("Timeout while waiting for machine to shut down.")
-				state.Put("error", err)
-				ui.Error(err.Error())
-				return multistep.ActionHalt
+				ui.Say("Timeout while waiting for machine to shut down, forcing it off...")
+				if err := driver.Stop(vmName); err != nil {
+					err = fmt.Errorf("Error stopping VM: %s", err)
+					state.Put("error", err)
+					ui.Error(err.Error())
+					return multistep.ActionHalt
+				}
+				log.Println("VM shut down.")
+				return multistep.ActionContinue
 			default:
 				time.Sleep(500 * time.Millisecond)
 			}
@@ -5,6 +5,7 @@ package common
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -93,12 +94,37 @@ func TestStepShutdown_shutdownTimeout(t *testing.T) {
 	driver := state.Get("driver").(*DriverMock)
 	driver.IsRunningReturn = true
 
-	go func() {
-		time.Sleep(2 * time.Second)
-		driver.Lock()
-		defer driver.Unlock()
-		driver.IsRunningReturn = false
-	}()
+	// Test the run
+	start := time.Now()
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Test that Stop was called after the timeout
+	if driver.StopName != "foo" {
+		t.Fatal("should call stop")
+	}
+	if elapsed := time.Since(start); elapsed < step.Timeout {
+		t.Fatalf("stop called before the timeout: %s", elapsed)
+	}
+}
+
+func TestStepShutdown_shutdownTimeoutStopError(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "poweroff"
+	step.Timeout = 1 * time.Second
+
+	comm := new(packersdk.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IsRunningReturn = true
+	driver.StopErr = errors.New("stop failed")
 
 	// Test the run
 	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
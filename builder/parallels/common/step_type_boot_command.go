@@ -25,6 +25,7 @@ type bootCommandTemplateData struct {
 type StepTypeBootCommand struct {
 	BootCommand    string
 	BootWait       time.Duration
+	HTTPAddress    string
 	HostInterfaces []string
 	VMName         string
 	Ctx            interpolate.Context
@@ -56,8 +57,13 @@ func (s *StepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 
 	hostIP := "0.0.0.0"
 
-	if len(s.HostInterfaces) > 0 {
-		// Determine the host IP
+	if s.HTTPAddress != "" && s.HTTPAddress != "0.0.0.0" {
+		// The HTTP server is bound to a specific address, so the guest must
+		// reach it there
+		hostIP = s.HTTPAddress
+	} else if len(s.HostInterfaces) > 0 && s.BootCommand != "" && httpPort != 0 {
+		// Determine the host IP. It is only needed for the boot command to
+		// reach the HTTP server, so it isn't looked up otherwise
 		ipFinder := &IfconfigIPFinder{Devices: s.HostInterfaces}
 
 		ip, err := ipFinder.HostIP()
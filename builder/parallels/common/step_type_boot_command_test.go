@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepTypeBootCommand_impl(t *testing.T) {
+	var _ multistep.Step = new(StepTypeBootCommand)
+}
+
+func TestStepTypeBootCommand_HTTPAddress(t *testing.T) {
+	state := testState(t)
+	state.Put("http_port", 8080)
+	step := &StepTypeBootCommand{
+		BootCommand:    "a",
+		HTTPAddress:    "192.168.1.10",
+		HostInterfaces: []string{"does-not-exist"},
+		VMName:         "foo",
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	if ip := state.Get("http_ip").(string); ip != "192.168.1.10" {
+		t.Fatalf("bad: %s", ip)
+	}
+	if len(driver.SendKeyScanCodesCalls) == 0 {
+		t.Fatal("boot command should be typed")
+	}
+}
+
+func TestStepTypeBootCommand_noHostIPLookup(t *testing.T) {
+	cases := map[string]struct {
+		bootCommand string
+		httpPort    int
+	}{
+		"no boot command": {"", 8080},
+		"no http server":  {"a", 0},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			state := testState(t)
+			state.Put("http_port", tc.httpPort)
+			step := &StepTypeBootCommand{
+				BootCommand:    tc.bootCommand,
+				HostInterfaces: []string{"does-not-exist"},
+				VMName:         "foo",
+			}
+
+			// Test the run
+			if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+				t.Fatalf("bad action: %#v", action)
+			}
+			if _, ok := state.GetOk("error"); ok {
+				t.Fatal("should NOT have error")
+			}
+
+			if ip := state.Get("http_ip").(string); ip != "0.0.0.0" {
+				t.Fatalf("bad: %s", ip)
+			}
+		})
+	}
+}
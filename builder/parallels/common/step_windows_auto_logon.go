@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+const winlogonRegistryPath = `HKLM:\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Winlogon`
+
+// StepSetWindowsAutoLogon is a step that configures a Windows guest to log on
+// automatically by writing the Winlogon registry values with "prlctl exec".
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	<nothing>
+type StepSetWindowsAutoLogon struct {
+	Enabled  bool
+	Username string
+	Password string
+}
+
+// Run writes the auto-logon registry values.
+func (s *StepSetWindowsAutoLogon) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Enabled {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	// The password is passed to prlctl on the command line, so make sure it
+	// never ends up in the logs.
+	packersdk.LogSecretFilter.Set(s.Password)
+
+	script := strings.Join([]string{
+		fmt.Sprintf("$path = '%s'", winlogonRegistryPath),
+		"Set-ItemProperty -Path $path -Name AutoAdminLogon -Value '1'",
+		fmt.Sprintf("Set-ItemProperty -Path $path -Name DefaultUserName -Value '%s'", powershellEscape(s.Username)),
+		fmt.Sprintf("Set-ItemProperty -Path $path -Name DefaultPassword -Value '%s'", powershellEscape(s.Password)),
+	}, "; ")
+
+	ui.Say("Configuring Windows auto-logon...")
+	if err := driver.Prlctl(powershellExecCommand(vmName, script)...); err != nil {
+		err = fmt.Errorf("Error configuring Windows auto-logon: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (s *StepSetWindowsAutoLogon) Cleanup(state multistep.StateBag) {}
+
+// StepRemoveWindowsAutoLogon is a step that removes the registry values
+// written by StepSetWindowsAutoLogon once provisioning has finished.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	<nothing>
+type StepRemoveWindowsAutoLogon struct {
+	Enabled bool
+}
+
+// Run removes the auto-logon registry values.
+func (s *StepRemoveWindowsAutoLogon) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Enabled {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	script := strings.Join([]string{
+		fmt.Sprintf("$path = '%s'", winlogonRegistryPath),
+		"Remove-ItemProperty -Path $path -Name AutoAdminLogon,DefaultPassword -ErrorAction SilentlyContinue",
+	}, "; ")
+
+	ui.Say("Removing Windows auto-logon configuration...")
+	if err := driver.Prlctl(powershellExecCommand(vmName, script)...); err != nil {
+		err = fmt.Errorf("Error removing Windows auto-logon configuration: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup does nothing.
+func (s *StepRemoveWindowsAutoLogon) Cleanup(state multistep.StateBag) {}
+
+// powershellExecCommand returns the prlctl arguments to run the given
+// PowerShell script inside the guest.
+func powershellExecCommand(vmName, script string) []string {
+	return []string{
+		"exec", vmName,
+		"powershell.exe", "-NoProfile", "-NonInteractive",
+		"-Command", script,
+	}
+}
+
+// powershellEscape escapes a value to be used inside a single-quoted
+// PowerShell string.
+func powershellEscape(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
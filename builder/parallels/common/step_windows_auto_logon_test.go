@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepSetWindowsAutoLogon_impl(t *testing.T) {
+	var _ multistep.Step = new(StepSetWindowsAutoLogon)
+	var _ multistep.Step = new(StepRemoveWindowsAutoLogon)
+}
+
+func TestStepSetWindowsAutoLogon(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSetWindowsAutoLogon{
+		Enabled:  true,
+		Username: "vagrant",
+		Password: "it's secret",
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	if len(driver.PrlctlCalls) != 1 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+	command := driver.PrlctlCalls[0]
+	if command[0] != "exec" || command[1] != "foo" {
+		t.Fatalf("bad: %#v", command)
+	}
+	script := command[len(command)-1]
+	if !strings.Contains(script, "-Name DefaultUserName -Value 'vagrant'") {
+		t.Fatalf("bad script: %s", script)
+	}
+	if !strings.Contains(script, "-Name DefaultPassword -Value 'it''s secret'") {
+		t.Fatalf("bad script: %s", script)
+	}
+}
+
+func TestStepSetWindowsAutoLogon_disabled(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := new(StepSetWindowsAutoLogon)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRemoveWindowsAutoLogon(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepRemoveWindowsAutoLogon{Enabled: true}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	if len(driver.PrlctlCalls) != 1 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+	script := driver.PrlctlCalls[0][len(driver.PrlctlCalls[0])-1]
+	if !strings.Contains(script, "Remove-ItemProperty") {
+		t.Fatalf("bad script: %s", script)
+	}
+}
@@ -35,11 +35,14 @@ type Config struct {
 	common.PackerConfig                 `mapstructure:",squash"`
 	commonsteps.HTTPConfig              `mapstructure:",squash"`
 	bootcommand.BootConfig              `mapstructure:",squash"`
+	parallelscommon.DriverConfig        `mapstructure:",squash"`
 	parallelscommon.OutputConfig        `mapstructure:",squash"`
 	parallelscommon.HWConfig            `mapstructure:",squash"`
 	parallelscommon.PrlctlConfig        `mapstructure:",squash"`
 	parallelscommon.PrlctlPostConfig    `mapstructure:",squash"`
 	parallelscommon.PrlctlVersionConfig `mapstructure:",squash"`
+	parallelscommon.RunConfig           `mapstructure:",squash"`
+	parallelscommon.SharedFolderConfig  `mapstructure:",squash"`
 	shutdowncommand.ShutdownConfig      `mapstructure:",squash"`
 	parallelscommon.SSHConfig           `mapstructure:",squash"`
 	// IPSWConfig is the configuration for the IPSW file
@@ -51,7 +54,8 @@ type Config struct {
 	// host should be searched for a IP address. The first IP address found on one
 	// of these will be used as `{{ .HTTPIP }}` in the boot_command. Defaults to
 	// ["en0", "en1", "en2", "en3", "en4", "en5", "en6", "en7", "en8", "en9",
-	// "ppp0", "ppp1", "ppp2"].
+	// "ppp0", "ppp1", "ppp2"]. If `http_bind_address` is set to a specific
+	// address, that address is used as `{{ .HTTPIP }}` instead.
 	HostInterfaces []string `mapstructure:"host_interfaces" required:"false"`
 	// This is the name of the PVM directory for the new
 	// virtual machine, without the file extension. By default this is
@@ -90,12 +94,15 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	errs = packersdk.MultiErrorAppend(errs, ipswErrs...)
 
 	errs = packersdk.MultiErrorAppend(errs, b.config.HTTPConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.DriverConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(
 		errs, b.config.OutputConfig.Prepare(&b.config.ctx, &b.config.PackerConfig)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.HWConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.PrlctlConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.PrlctlPostConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.PrlctlVersionConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.RunConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.SharedFolderConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.ShutdownConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.SSHConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.BootConfig.Prepare(&b.config.ctx)...)
@@ -120,6 +127,16 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 				"will forcibly halt the virtual machine, which may result in data loss.")
 	}
 
+	if b.config.ValidateEnvironment {
+		check := &parallelscommon.EnvironmentCheck{
+			DriverConfig: &b.config.DriverConfig,
+			OutputDir:    b.config.OutputDir,
+			DiskSizeMB:   uint64(b.config.DiskSize),
+			HTTPConfig:   &b.config.HTTPConfig,
+		}
+		errs = packersdk.MultiErrorAppend(errs, check.Validate()...)
+	}
+
 	if errs != nil && len(errs.Errors) > 0 {
 		return nil, warnings, errs
 	}
@@ -129,12 +146,16 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 
 func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
 	// Create the driver that we'll use to communicate with Parallels
-	driver, err := parallelscommon.NewDriver()
+	driver, err := parallelscommon.NewDriver(ctx, &b.config.DriverConfig)
 	if err != nil {
 		return nil, fmt.Errorf("Failed creating Parallels driver: %s", err)
 	}
+	defer driver.Close()
 
 	steps := []multistep.Step{
+		&parallelscommon.StepCheckVMName{
+			VMName: b.config.VMName,
+		},
 		&commonsteps.StepDownload{
 			Checksum:    b.config.IPSWConfig.IPSWChecksum,
 			Description: "IPSW",
@@ -149,10 +170,26 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		},
 		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
 		new(stepCreateVM),
+		&parallelscommon.StepSetCPUTopology{
+			Sockets:        b.config.HWConfig.CPUSockets,
+			CoresPerSocket: b.config.HWConfig.CPUCoresPerSocket,
+			ThreadsPerCore: b.config.HWConfig.CPUThreadsPerCore,
+		},
+		&parallelscommon.StepSharedFolders{
+			SharedFolders: b.config.SharedFolders,
+		},
+		&parallelscommon.StepConfigureHeadless{
+			Headless:       b.config.Headless,
+			VNCBindAddress: b.config.VNCBindAddress,
+			VNCPassword:    b.config.VNCPassword,
+			VNCPortMin:     b.config.VNCPortMin,
+			VNCPortMax:     b.config.VNCPortMax,
+		},
 		&parallelscommon.StepRun{},
 		&parallelscommon.StepTypeBootCommand{
 			BootWait:       b.config.BootWait,
 			BootCommand:    b.config.FlatBootCommand(),
+			HTTPAddress:    b.config.HTTPAddress,
 			HostInterfaces: b.config.HostInterfaces,
 			VMName:         b.config.VMName,
 			Ctx:            b.config.ctx,
@@ -183,12 +220,18 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	}
 
 	steps = append(steps, []multistep.Step{
+		new(parallelscommon.StepRestoreHeadless),
+		new(parallelscommon.StepRemoveSharedFolders),
 		&parallelscommon.StepPrlctl{
 			Commands: b.config.PrlctlPost,
 			Ctx:      b.config.ctx,
 		},
 	}...)
 
+	if b.config.OutputManifest {
+		steps = parallelscommon.TimeSteps(steps, b.config.PackerOnError)
+	}
+
 	// Setup the state bag
 	state := new(multistep.BasicStateBag)
 	state.Put("config", &b.config)
@@ -215,6 +258,20 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		return nil, errors.New("Build was halted.")
 	}
 
-	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
+	generatedData := map[string]interface{}{
+		"generated_data": state.Get("generated_data"),
+	}
+	if b.config.OutputManifest {
+		ui.Say("Writing build manifest...")
+		manifest, err := parallelscommon.WriteManifest(driver, b.config.OutputDir, "", state)
+		if err != nil {
+			return nil, err
+		}
+		generatedData["manifest"] = manifest
+	}
+	if b.config.KeepRegistered {
+		return parallelscommon.NewRegisteredArtifact(
+			b.config.OutputDir, b.config.VMName, driver, generatedData)
+	}
 	return parallelscommon.NewArtifact(b.config.OutputDir, generatedData)
 }
@@ -10,6 +10,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
 	"github.com/hashicorp/hcl/v2/hcldec"
@@ -26,6 +29,8 @@ import (
 
 const BuilderId = "rickard-von-essen.parallels"
 
+var bootDeviceRe = regexp.MustCompile(`^(hdd|cdrom|net|fdd|usb)\d+$`)
+
 type Builder struct {
 	config Config
 	runner multistep.Runner
@@ -37,17 +42,34 @@ type Config struct {
 	commonsteps.ISOConfig               `mapstructure:",squash"`
 	commonsteps.FloppyConfig            `mapstructure:",squash"`
 	bootcommand.BootConfig              `mapstructure:",squash"`
+	parallelscommon.DriverConfig        `mapstructure:",squash"`
 	parallelscommon.OutputConfig        `mapstructure:",squash"`
 	parallelscommon.HWConfig            `mapstructure:",squash"`
 	parallelscommon.PrlctlConfig        `mapstructure:",squash"`
 	parallelscommon.PrlctlPostConfig    `mapstructure:",squash"`
 	parallelscommon.PrlctlVersionConfig `mapstructure:",squash"`
+	parallelscommon.RunConfig           `mapstructure:",squash"`
+	parallelscommon.SharedFolderConfig  `mapstructure:",squash"`
 	shutdowncommand.ShutdownConfig      `mapstructure:",squash"`
 	parallelscommon.SSHConfig           `mapstructure:",squash"`
 	parallelscommon.ToolsConfig         `mapstructure:",squash"`
+	// The devices to boot the VM from, in order of preference. The devices
+	// are referred to by their Parallels Desktop names, such as "hdd0",
+	// "cdrom0", "net0" or "fdd0". Defaults to ["hdd0", "cdrom0", "net0"], so
+	// the installer on the ISO boots while the hard disk is still empty and
+	// the installed OS boots afterwards. If the order doesn't start with a
+	// hard disk, the hard disks are moved to the front once the VM has been
+	// shut down, so that the resulting VM boots the installed OS.
+	BootOrder []string `mapstructure:"boot_order" required:"false"`
 	// The size, in megabytes, of the hard disk to create
 	// for the VM. By default, this is 40000 (about 40 GB).
 	DiskSize uint `mapstructure:"disk_size" required:"false"`
+	// The sizes, in megabytes, of additional hard disks to create and attach
+	// to the VM after the primary disk. The disks use the same `disk_type` and
+	// `hard_drive_interface` as the primary disk and are stored in the PVM
+	// bundle, so they are part of the artifact. By default no additional
+	// disks are created.
+	AdditionalDiskSize []uint `mapstructure:"disk_additional_size" required:"false"`
 	// The type for image file based virtual disk drives,
 	// defaults to expand. Valid options are expand (expanding disk) that the
 	// image file is small initially and grows in size as you add data to it, and
@@ -56,23 +78,77 @@ type Config struct {
 	// perform faster than expanding disks. skip_compaction will be set to true
 	// automatically for plain disks.
 	DiskType string `mapstructure:"disk_type" required:"false"`
+	// Custom `prlctl` commands to execute at named points of the build. The
+	// keys are extension points and the values have the same format as
+	// `prlctl`. Valid extension points are `pre_start` and `post_start`
+	// (around the first boot of the VM), `pre_shutdown` and `post_shutdown`
+	// (around the shutdown of the VM), and `pre_export` and `post_export`
+	// (around the final disk compaction and snapshot).
+	Extensions map[string][][]string `mapstructure:"extensions" required:"false"`
+	// Enable Secure Boot in the EFI firmware of the VM. Requires `firmware` to
+	// be "efi". Defaults to false.
+	EFISecureBoot bool `mapstructure:"efi_secure_boot" required:"false"`
+	// The firmware of the VM. Valid options are "bios" and "efi". By default
+	// the firmware chosen by Parallels Desktop for the guest OS is used.
+	Firmware string `mapstructure:"firmware" required:"false"`
 	// The guest OS type being installed. By default
 	// this is "other", but you can get dramatic performance improvements by
 	// setting this to the proper value. To view all available values for this run
 	// prlctl create x --distribution list. Setting the correct value hints to
 	// Parallels Desktop how to optimize the virtual hardware to work best with
-	// that operating system.
+	// that operating system. The value is lowercased and common aliases such
+	// as "ubuntu64" are mapped to their Parallels Desktop name. Unknown values
+	// produce a warning listing the closest matches. With
+	// `validate_environment`, the value is checked against the installed
+	// Parallels Desktop instead, and an unsupported value is an error.
 	GuestOSType string `mapstructure:"guest_os_type" required:"false"`
 	// The type of controller that the hard
 	// drives are attached to, defaults to "sata". Valid options are "sata", "ide",
-	// and "scsi".
+	// "scsi", and "nvme".
 	HardDriveInterface string `mapstructure:"hard_drive_interface" required:"false"`
 	// A list of which interfaces on the
 	// host should be searched for a IP address. The first IP address found on one
 	// of these will be used as `{{ .HTTPIP }}` in the boot_command. Defaults to
 	// ["en0", "en1", "en2", "en3", "en4", "en5", "en6", "en7", "en8", "en9",
-	// "ppp0", "ppp1", "ppp2"].
+	// "ppp0", "ppp1", "ppp2"]. If `http_bind_address` is set to a specific
+	// address, that address is used as `{{ .HTTPIP }}` instead.
 	HostInterfaces []string `mapstructure:"host_interfaces" required:"false"`
+	// Commands to execute on the host at the same extension points as
+	// `extensions`. Each command is an array of strings, where the first
+	// element is the program to run. The templates `{{ .Name }}` and
+	// `{{ .OutputDir }}` are replaced with the name of the VM and the output
+	// directory.
+	HostCommands map[string][][]string `mapstructure:"host_commands" required:"false"`
+	// The type of controller that the CD/DVD ROM device holding the ISO is
+	// attached to. Valid options are "ide", "sata", and "scsi". By default the
+	// controller chosen by Parallels Desktop for the guest OS is used.
+	ISOInterface string `mapstructure:"iso_interface" required:"false"`
+	// The order in which the `iso_urls` are tried. Valid options are "first"
+	// (try the URLs in the order they are listed), "random" (try the URLs in a
+	// random order), and "fastest" (send a HEAD request to every URL in
+	// parallel and try the one that answers quickest first). The URLs are not
+	// reordered when the ISO is already in the cache. Defaults to "first".
+	ISOURLStrategy string `mapstructure:"iso_url_strategy" required:"false"`
+	// The amount of time to wait for each URL to answer when
+	// `iso_url_strategy` is "fastest". URLs that do not answer in time are
+	// tried last. Defaults to "5s".
+	ISOURLProbeTimeout time.Duration `mapstructure:"iso_url_probe_timeout" required:"false"`
+	// Report the size of the output directory before and after the OS
+	// installation, and again after provisioning. The final size in bytes is
+	// available to provisioners and post-processors as the `disk_growth`
+	// generated data key. Defaults to false.
+	ReportDiskGrowth bool `mapstructure:"report_disk_growth" required:"false"`
+	// Attach a serial port to the VM that logs its output to
+	// `<vm_name>-serial.txt` in the output directory. This is useful to debug
+	// unattended installs that fail before the communicator is available.
+	// The serial port is removed once the VM has been shut down, and the log
+	// file is kept. Defaults to false.
+	SerialLog bool `mapstructure:"serial_log" required:"false"`
+	// The name of a snapshot to take of the VM once it has been shut down, the
+	// installation media have been detached and its disk has been compacted.
+	// The snapshot is stored in the resulting PVM, so the artifact can be
+	// used as a base for linked clones. By default no snapshot is taken.
+	SnapshotName string `mapstructure:"snapshot_name" required:"false"`
 	// Virtual disk image is compacted at the end of
 	// the build process using prl_disk_tool utility (except for the case that
 	// disk_type is set to plain). In certain rare cases, this might corrupt
@@ -83,6 +159,13 @@ type Config struct {
 	// virtual machine, without the file extension. By default this is
 	// "packer-BUILDNAME", where "BUILDNAME" is the name of the build.
 	VMName string `mapstructure:"vm_name" required:"false"`
+	// Configure a Windows guest to log on automatically with the
+	// `winrm_username` and `winrm_password` credentials before the
+	// provisioners run. The auto-logon registry values are removed again once
+	// provisioning has finished. This requires Parallels Tools to be installed
+	// in the guest and only takes effect when `communicator` is "winrm".
+	// Defaults to false.
+	WindowsAutoLogon bool `mapstructure:"windows_auto_logon" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -97,6 +180,8 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		InterpolateFilter: &interpolate.RenderFilter{
 			Exclude: []string{
 				"boot_command",
+				"extensions",
+				"host_commands",
 				"prlctl",
 				"prlctl_post",
 				"parallels_tools_guest_path",
@@ -117,17 +202,24 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 
 	errs = packersdk.MultiErrorAppend(errs, b.config.HTTPConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.FloppyConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.DriverConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(
 		errs, b.config.OutputConfig.Prepare(&b.config.ctx, &b.config.PackerConfig)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.HWConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.PrlctlConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.PrlctlPostConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.PrlctlVersionConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.RunConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.SharedFolderConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.ShutdownConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.SSHConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.ToolsConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.BootConfig.Prepare(&b.config.ctx)...)
 
+	if len(b.config.BootOrder) == 0 {
+		b.config.BootOrder = []string{"hdd0", "cdrom0", "net0"}
+	}
+
 	if b.config.DiskSize == 0 {
 		b.config.DiskSize = 40000
 	}
@@ -149,10 +241,25 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 			"en8", "en9", "ppp0", "ppp1", "ppp2"}
 	}
 
+	if b.config.ISOURLStrategy == "" {
+		b.config.ISOURLStrategy = "first"
+	}
+
+	if b.config.ISOURLProbeTimeout == 0 {
+		b.config.ISOURLProbeTimeout = 5 * time.Second
+	}
+
 	if b.config.VMName == "" {
 		b.config.VMName = fmt.Sprintf("packer-%s", b.config.PackerBuildName)
 	}
 
+	for i, size := range b.config.AdditionalDiskSize {
+		if size == 0 {
+			errs = packersdk.MultiErrorAppend(
+				errs, fmt.Errorf("disk_additional_size[%d] must be greater than 0", i))
+		}
+	}
+
 	if b.config.DiskType != "expand" && b.config.DiskType != "plain" {
 		errs = packersdk.MultiErrorAppend(
 			errs, errors.New("disk_type can only be expand, or plain"))
@@ -164,45 +271,153 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 			"'skip_compaction' is enforced to be true for plain disks.")
 	}
 
-	if b.config.HardDriveInterface != "ide" && b.config.HardDriveInterface != "sata" && b.config.HardDriveInterface != "scsi" {
+	for _, device := range b.config.BootOrder {
+		if !bootDeviceRe.MatchString(device) {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf(
+				"boot_order: invalid device %q, must be like hdd0, cdrom0, net0, fdd0 or usb0", device))
+		}
+	}
+
+	switch b.config.Firmware {
+	case "", "bios", "efi":
+	default:
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("firmware can only be bios, or efi"))
+	}
+
+	if b.config.EFISecureBoot && b.config.Firmware != "efi" {
 		errs = packersdk.MultiErrorAppend(
-			errs, errors.New("hard_drive_interface can only be ide, sata, or scsi"))
+			errs, errors.New("efi_secure_boot requires firmware to be efi"))
 	}
 
+	switch b.config.ISOInterface {
+	case "", "ide", "sata", "scsi":
+	default:
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("iso_interface can only be ide, sata, or scsi"))
+	}
+
+	switch b.config.ISOURLStrategy {
+	case "first", "random", "fastest":
+	default:
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("iso_url_strategy can only be first, random, or fastest"))
+	}
+
+	switch b.config.HardDriveInterface {
+	case "ide", "sata", "scsi", "nvme":
+	default:
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("hard_drive_interface can only be ide, sata, scsi, or nvme"))
+	}
+
+	for point := range b.config.Extensions {
+		if !isExtensionPoint(point) {
+			errs = packersdk.MultiErrorAppend(errs,
+				fmt.Errorf("extensions: unknown extension point %q, must be one of %s",
+					point, strings.Join(extensionPoints, ", ")))
+		}
+	}
+
+	for point, commands := range b.config.HostCommands {
+		if !isExtensionPoint(point) {
+			errs = packersdk.MultiErrorAppend(errs,
+				fmt.Errorf("host_commands: unknown extension point %q, must be one of %s",
+					point, strings.Join(extensionPoints, ", ")))
+		}
+		for _, command := range commands {
+			if len(command) == 0 {
+				errs = packersdk.MultiErrorAppend(errs,
+					fmt.Errorf("host_commands: empty command at extension point %q", point))
+			}
+		}
+	}
+
+	b.config.GuestOSType = normalizeGuestOSType(b.config.GuestOSType)
+
 	// Warnings
+	if b.config.WindowsAutoLogon && b.config.SSHConfig.Comm.Type != "winrm" {
+		warnings = append(warnings,
+			"'windows_auto_logon' only takes effect with the winrm communicator.")
+	}
+
+	// The environment check validates the guest OS type against the
+	// installed Parallels Desktop instead
+	if !b.config.ValidateEnvironment && !isKnownGuestOSType(b.config.GuestOSType) {
+		warning := fmt.Sprintf("guest_os_type %q is not a known Parallels Desktop "+
+			"distribution, creating the VM may fail.", b.config.GuestOSType)
+		if suggestions := parallelscommon.SuggestGuestOSTypes(b.config.GuestOSType, knownGuestOSTypes); len(suggestions) > 0 {
+			warning += fmt.Sprintf(" Did you mean %s?", strings.Join(suggestions, ", "))
+		}
+		warnings = append(warnings, warning)
+	}
+
 	if b.config.ShutdownCommand == "" {
 		warnings = append(warnings,
 			"A shutdown_command was not specified. Without a shutdown command, Packer\n"+
 				"will forcibly halt the virtual machine, which may result in data loss.")
 	}
 
+	if b.config.ValidateEnvironment {
+		diskSize := uint64(b.config.DiskSize)
+		for _, size := range b.config.AdditionalDiskSize {
+			diskSize += uint64(size)
+		}
+		check := &parallelscommon.EnvironmentCheck{
+			DriverConfig: &b.config.DriverConfig,
+			OutputDir:    b.config.OutputDir,
+			DiskSizeMB:   diskSize,
+			HTTPConfig:   &b.config.HTTPConfig,
+			GuestOSType:  b.config.GuestOSType,
+		}
+		errs = packersdk.MultiErrorAppend(errs, check.Validate()...)
+	}
+
+	var generatedData []string
+	if b.config.ReportDiskGrowth {
+		generatedData = append(generatedData, "disk_growth")
+	}
+
 	if errs != nil && len(errs.Errors) > 0 {
-		return nil, warnings, errs
+		return generatedData, warnings, errs
 	}
 
-	return nil, warnings, nil
+	return generatedData, warnings, nil
 }
 
 func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
 	// Create the driver that we'll use to communicate with Parallels
-	driver, err := parallelscommon.NewDriver()
+	driver, err := parallelscommon.NewDriver(ctx, &b.config.DriverConfig)
 	if err != nil {
 		return nil, fmt.Errorf("Failed creating Parallels driver: %s", err)
 	}
+	defer driver.Close()
+
+	download := &commonsteps.StepDownload{
+		Checksum:    b.config.ISOChecksum,
+		Description: "ISO",
+		Extension:   b.config.TargetExtension,
+		ResultKey:   "iso_path",
+		TargetPath:  b.config.TargetPath,
+		Url:         b.config.ISOUrls,
+	}
+
+	// The URLs are only probed if the ISO has to be downloaded
+	if len(download.Url) > 1 && b.config.ISOURLStrategy != "first" && !isoCached(*download) {
+		download.Url = orderISOUrls(ctx, download.Url, b.config.ISOURLStrategy, b.config.ISOURLProbeTimeout)
+		ui.Say(fmt.Sprintf("Selected ISO URL using the %q strategy: %s",
+			b.config.ISOURLStrategy, download.Url[0]))
+	}
 
 	steps := []multistep.Step{
+		&parallelscommon.StepCheckVMName{
+			VMName: b.config.VMName,
+		},
 		&parallelscommon.StepPrepareParallelsTools{
 			ParallelsToolsFlavor: b.config.ParallelsToolsFlavor,
 			ParallelsToolsMode:   b.config.ParallelsToolsMode,
 		},
-		&commonsteps.StepDownload{
-			Checksum:    b.config.ISOChecksum,
-			Description: "ISO",
-			Extension:   b.config.TargetExtension,
-			ResultKey:   "iso_path",
-			TargetPath:  b.config.TargetPath,
-			Url:         b.config.ISOUrls,
-		},
+		download,
 		&parallelscommon.StepOutputDir{
 			Force: b.config.PackerForce,
 			Path:  b.config.OutputDir,
@@ -214,9 +429,15 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		},
 		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
 		new(stepCreateVM),
+		&parallelscommon.StepSetCPUTopology{
+			Sockets:        b.config.HWConfig.CPUSockets,
+			CoresPerSocket: b.config.HWConfig.CPUCoresPerSocket,
+			ThreadsPerCore: b.config.HWConfig.CPUThreadsPerCore,
+		},
 		new(stepCreateDisk),
 		new(stepSetBootOrder),
 		new(stepAttachISO),
+		new(stepAttachSerialLog),
 		&parallelscommon.StepAttachParallelsTools{
 			ParallelsToolsMode: b.config.ParallelsToolsMode,
 		},
@@ -225,10 +446,28 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Commands: b.config.Prlctl,
 			Ctx:      b.config.ctx,
 		},
+		&parallelscommon.StepReportDiskGrowth{
+			Enabled: b.config.ReportDiskGrowth,
+			Path:    b.config.OutputDir,
+			Phase:   "before OS installation",
+		},
+		&stepRunExtension{Point: extensionPreStart},
+		&parallelscommon.StepSharedFolders{
+			SharedFolders: b.config.SharedFolders,
+		},
+		&parallelscommon.StepConfigureHeadless{
+			Headless:       b.config.Headless,
+			VNCBindAddress: b.config.VNCBindAddress,
+			VNCPassword:    b.config.VNCPassword,
+			VNCPortMin:     b.config.VNCPortMin,
+			VNCPortMax:     b.config.VNCPortMax,
+		},
 		&parallelscommon.StepRun{},
+		&stepRunExtension{Point: extensionPostStart},
 		&parallelscommon.StepTypeBootCommand{
 			BootWait:       b.config.BootWait,
 			BootCommand:    b.config.FlatBootCommand(),
+			HTTPAddress:    b.config.HTTPAddress,
 			HostInterfaces: b.config.HostInterfaces,
 			VMName:         b.config.VMName,
 			Ctx:            b.config.ctx,
@@ -239,6 +478,11 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Host:      parallelscommon.CommHost(b.config.SSHConfig.Comm.Host()),
 			SSHConfig: b.config.SSHConfig.Comm.SSHConfigFunc(),
 		},
+		&parallelscommon.StepReportDiskGrowth{
+			Enabled: b.config.ReportDiskGrowth,
+			Path:    b.config.OutputDir,
+			Phase:   "after OS installation",
+		},
 		&parallelscommon.StepUploadVersion{
 			Path: b.config.PrlctlVersionFile,
 		},
@@ -248,21 +492,50 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			ParallelsToolsMode:      b.config.ParallelsToolsMode,
 			Ctx:                     b.config.ctx,
 		},
+		&parallelscommon.StepSetWindowsAutoLogon{
+			Enabled:  b.config.WindowsAutoLogon && b.config.SSHConfig.Comm.Type == "winrm",
+			Username: b.config.SSHConfig.Comm.WinRMUser,
+			Password: b.config.SSHConfig.Comm.WinRMPassword,
+		},
 		new(commonsteps.StepProvision),
+		&parallelscommon.StepReportDiskGrowth{
+			Enabled: b.config.ReportDiskGrowth,
+			Path:    b.config.OutputDir,
+			Phase:   "after provisioning",
+		},
+		&parallelscommon.StepRemoveWindowsAutoLogon{
+			Enabled: b.config.WindowsAutoLogon && b.config.SSHConfig.Comm.Type == "winrm",
+		},
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.SSHConfig.Comm,
 		},
+		&stepRunExtension{Point: extensionPreShutdown},
 		&parallelscommon.StepShutdown{
 			Command: b.config.ShutdownCommand,
 			Timeout: b.config.ShutdownTimeout,
 		},
+		&stepRunExtension{Point: extensionPostShutdown},
+		new(parallelscommon.StepRestoreHeadless),
+		new(stepRemoveSerialLog),
+		new(stepResetBootOrder),
+		new(parallelscommon.StepRemoveSharedFolders),
+		new(parallelscommon.StepDetachMedia),
 		&parallelscommon.StepPrlctl{
 			Commands: b.config.PrlctlPost,
 			Ctx:      b.config.ctx,
 		},
+		&stepRunExtension{Point: extensionPreExport},
 		&parallelscommon.StepCompactDisk{
 			Skip: b.config.SkipCompaction,
 		},
+		&parallelscommon.StepCreateSnapshot{
+			Name: b.config.SnapshotName,
+		},
+		&stepRunExtension{Point: extensionPostExport},
+	}
+
+	if b.config.OutputManifest {
+		steps = parallelscommon.TimeSteps(steps, b.config.PackerOnError)
 	}
 
 	// Setup the state bag
@@ -291,6 +564,20 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		return nil, errors.New("Build was halted.")
 	}
 
-	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
+	generatedData := map[string]interface{}{
+		"generated_data": state.Get("generated_data"),
+	}
+	if b.config.OutputManifest {
+		ui.Say("Writing build manifest...")
+		manifest, err := parallelscommon.WriteManifest(driver, b.config.OutputDir, b.config.ISOChecksum, state)
+		if err != nil {
+			return nil, err
+		}
+		generatedData["manifest"] = manifest
+	}
+	if b.config.KeepRegistered {
+		return parallelscommon.NewRegisteredArtifact(
+			b.config.OutputDir, b.config.VMName, driver, generatedData)
+	}
 	return parallelscommon.NewArtifact(b.config.OutputDir, generatedData)
 }
@@ -6,7 +6,9 @@ package iso
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/common"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -52,6 +54,44 @@ func TestBuilderPrepare_Defaults(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_Firmware(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with a bad
+	config["firmware"] = "efi64"
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test secure boot without efi
+	config["firmware"] = "bios"
+	config["efi_secure_boot"] = true
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a good
+	config["firmware"] = "efi"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
 func TestBuilderPrepare_FloppyFiles(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -101,6 +141,46 @@ func TestBuilderPrepare_InvalidFloppies(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_BootOrder(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test the default
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(b.config.BootOrder, []string{"hdd0", "cdrom0", "net0"}) {
+		t.Fatalf("bad: %#v", b.config.BootOrder)
+	}
+
+	// Test with a bad
+	config["boot_order"] = []string{"dvd", "disk"}
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a good
+	config["boot_order"] = []string{"cdrom0", "hdd0"}
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
 func TestBuilderPrepare_DiskSize(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -133,6 +213,36 @@ func TestBuilderPrepare_DiskSize(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_AdditionalDiskSize(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with a bad size
+	config["disk_additional_size"] = []uint{0}
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with good sizes
+	config["disk_additional_size"] = []uint{10000, 20000}
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if !reflect.DeepEqual(b.config.AdditionalDiskSize, []uint{10000, 20000}) {
+		t.Fatalf("bad: %#v", b.config.AdditionalDiskSize)
+	}
+}
+
 func TestBuilderPrepare_DiskType(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -188,6 +298,49 @@ func TestBuilderPrepare_DiskType(t *testing.T) {
 
 }
 
+func TestBuilderPrepare_GuestOSType(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with an alias
+	config["guest_os_type"] = "Ubuntu_64"
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.GuestOSType != "ubuntu" {
+		t.Fatalf("bad: %s", b.config.GuestOSType)
+	}
+
+	// Test with a different case
+	config["guest_os_type"] = "Ubuntu"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.GuestOSType != "ubuntu" {
+		t.Fatalf("bad: %s", b.config.GuestOSType)
+	}
+
+	// Test with a typo
+	config["guest_os_type"] = "ubunto"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) != 1 || !strings.Contains(warns[0], "Did you mean ubuntu?") {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
 func TestBuilderPrepare_HardDriveInterface(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -227,6 +380,205 @@ func TestBuilderPrepare_HardDriveInterface(t *testing.T) {
 	if err != nil {
 		t.Fatalf("should not have error: %s", err)
 	}
+	// Test with nvme
+	config["hard_drive_interface"] = "nvme"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_ISOInterface(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with a bad
+	config["iso_interface"] = "nvme"
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a good
+	config["iso_interface"] = "ide"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_ISOURLStrategy(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test the default
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if b.config.ISOURLStrategy != "first" {
+		t.Fatalf("bad: %s", b.config.ISOURLStrategy)
+	}
+	if b.config.ISOURLProbeTimeout != 5*time.Second {
+		t.Fatalf("bad: %s", b.config.ISOURLProbeTimeout)
+	}
+
+	// Test with a bad
+	config["iso_url_strategy"] = "nearest"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a good
+	config["iso_url_strategy"] = "fastest"
+	config["iso_url_probe_timeout"] = "2s"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if b.config.ISOURLProbeTimeout != 2*time.Second {
+		t.Fatalf("bad: %s", b.config.ISOURLProbeTimeout)
+	}
+}
+
+func TestBuilderPrepare_ReportDiskGrowth(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test the default
+	generatedData, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(generatedData) != 0 {
+		t.Fatalf("bad: %#v", generatedData)
+	}
+
+	// Test when enabled
+	config["report_disk_growth"] = true
+	b = Builder{}
+	generatedData, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !reflect.DeepEqual(generatedData, []string{"disk_growth"}) {
+		t.Fatalf("bad: %#v", generatedData)
+	}
+}
+
+func TestBuilderPrepare_Extensions(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with a bad extension point
+	config["extensions"] = map[string]interface{}{
+		"pre_boot": [][]string{{"set", "{{.Name}}", "--on-crash", "restart"}},
+	}
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with good extension points
+	config["extensions"] = map[string]interface{}{
+		"pre_start":   [][]string{{"set", "{{.Name}}", "--on-crash", "restart"}},
+		"post_export": [][]string{{"set", "{{.Name}}", "--description", "built"}},
+	}
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	expected := [][]string{{"set", "{{.Name}}", "--on-crash", "restart"}}
+	if !reflect.DeepEqual(b.config.Extensions["pre_start"], expected) {
+		t.Fatalf("bad: %#v", b.config.Extensions["pre_start"])
+	}
+}
+
+func TestBuilderPrepare_HostCommands(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with a bad extension point
+	config["host_commands"] = map[string]interface{}{
+		"pre_boot": [][]string{{"cp", "license.txt", "{{.OutputDir}}"}},
+	}
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with an empty command
+	config["host_commands"] = map[string]interface{}{
+		"pre_export": [][]string{{}},
+	}
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a good extension point
+	config["host_commands"] = map[string]interface{}{
+		"pre_export": [][]string{{"cp", "license.txt", "{{.OutputDir}}"}},
+	}
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	expected := [][]string{{"cp", "license.txt", "{{.OutputDir}}"}}
+	if !reflect.DeepEqual(b.config.HostCommands["pre_export"], expected) {
+		t.Fatalf("bad: %#v", b.config.HostCommands["pre_export"])
+	}
 }
 
 func TestBuilderPrepare_InvalidKey(t *testing.T) {
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"strings"
+)
+
+// knownGuestOSTypes are the distributions accepted by
+// "prlctl create x --distribution list" in recent Parallels Desktop versions.
+// Unless the environment is validated, which queries the installed version,
+// this list is used to warn about unknown guest OS types.
+var knownGuestOSTypes = []string{
+	"centos", "chrome", "debian", "fedora", "fedora-core", "freebsd", "kali",
+	"linux", "linux-2.4", "macos", "macosx", "mageia", "mandriva", "mint",
+	"netbsd", "openbsd", "opensolaris", "opensuse", "os2", "other", "psbm",
+	"redhat", "rhel", "rhel7", "rhel8", "rhel9", "solaris", "suse", "ubuntu",
+	"win", "win-10", "win-11", "win-2003", "win-2008", "win-2012",
+	"win-2016", "win-2019", "win-2022", "win-7", "win-8", "win-8.1",
+	"xandros",
+}
+
+// guestOSTypeAliases maps common spellings of guest OS types, including the
+// names used by other hypervisors, to their Parallels Desktop equivalent.
+var guestOSTypeAliases = map[string]string{
+	"centos64":  "centos",
+	"debian64":  "debian",
+	"fedora64":  "fedora",
+	"freebsd64": "freebsd",
+	"rhel64":    "rhel",
+	"ubuntu64":  "ubuntu",
+	"win10":     "win-10",
+	"win11":     "win-11",
+	"windows10": "win-10",
+	"windows11": "win-11",
+}
+
+// normalizeGuestOSType returns the Parallels Desktop name of the given guest
+// OS type. It is lowercased and aliases are resolved ignoring underscores, so
+// "Ubuntu" becomes "ubuntu" and "Ubuntu_64" becomes "ubuntu".
+func normalizeGuestOSType(osType string) string {
+	osType = strings.ToLower(osType)
+	if alias, ok := guestOSTypeAliases[strings.ReplaceAll(osType, "_", "")]; ok {
+		return alias
+	}
+	return osType
+}
+
+// isKnownGuestOSType reports whether the given guest OS type is known.
+func isKnownGuestOSType(osType string) bool {
+	for _, t := range knownGuestOSTypes {
+		if t == osType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// isoURLProbe is the result of probing a single ISO URL.
+type isoURLProbe struct {
+	URL     string
+	Latency time.Duration
+	Err     error
+}
+
+// probeISOUrls issues a HEAD request to each of the given URLs in parallel
+// and sends the results over the returned channel as they arrive. Each probe
+// is cancelled once the timeout expires. The channel is closed after all of
+// the URLs have been probed.
+func probeISOUrls(ctx context.Context, urls []string, timeout time.Duration) <-chan isoURLProbe {
+	results := make(chan isoURLProbe, len(urls))
+	client := &http.Client{Timeout: timeout}
+
+	var wg sync.WaitGroup
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			results <- probeISOUrl(ctx, client, u)
+		}(u)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func probeISOUrl(ctx context.Context, client *http.Client, u string) isoURLProbe {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return isoURLProbe{URL: u, Err: err}
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return isoURLProbe{URL: u, Err: fmt.Errorf("unsupported scheme %q", parsed.Scheme)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return isoURLProbe{URL: u, Err: err}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return isoURLProbe{URL: u, Err: err}
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return isoURLProbe{URL: u, Err: fmt.Errorf("unexpected status %s", resp.Status)}
+	}
+
+	return isoURLProbe{URL: u, Latency: time.Since(start)}
+}
+
+// orderISOUrls returns the ISO URLs in the order they should be tried
+// according to the given strategy. With the "fastest" strategy, URLs that
+// responded are sorted by latency and the remaining URLs keep their original
+// order after them.
+func orderISOUrls(ctx context.Context, urls []string, strategy string, timeout time.Duration) []string {
+	ordered := make([]string, len(urls))
+	copy(ordered, urls)
+
+	switch strategy {
+	case "random":
+		rand.Shuffle(len(ordered), func(i, j int) {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	case "fastest":
+		latencies := make(map[string]time.Duration)
+		for result := range probeISOUrls(ctx, urls, timeout) {
+			if result.Err == nil {
+				latencies[result.URL] = result.Latency
+			}
+		}
+
+		sort.SliceStable(ordered, func(i, j int) bool {
+			li, iok := latencies[ordered[i]]
+			lj, jok := latencies[ordered[j]]
+			if iok && jok {
+				return li < lj
+			}
+			return iok && !jok
+		})
+	}
+
+	return ordered
+}
+
+// isoCached reports whether the ISO of the given download step is already in
+// the cache. Cached ISOs are stored under their checksum, so this is only
+// known when a checksum is set.
+func isoCached(download commonsteps.StepDownload) bool {
+	if download.Checksum == "" || download.Checksum == "none" || len(download.Url) == 0 {
+		return false
+	}
+
+	_, targetPath, err := download.UseSourceToFindCacheTarget(download.Url[0])
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(targetPath)
+	return err == nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+)
+
+func testISOServer(t *testing.T, delay time.Duration, status int) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestProbeISOUrls(t *testing.T) {
+	ok := testISOServer(t, 0, http.StatusOK)
+	missing := testISOServer(t, 0, http.StatusNotFound)
+	urls := []string{ok.URL, missing.URL, "file:///foo.iso"}
+
+	var failed []string
+	var succeeded []string
+	for result := range probeISOUrls(context.Background(), urls, time.Second) {
+		if result.Err != nil {
+			failed = append(failed, result.URL)
+		} else {
+			succeeded = append(succeeded, result.URL)
+		}
+	}
+
+	if !reflect.DeepEqual(succeeded, []string{ok.URL}) {
+		t.Fatalf("bad: %#v", succeeded)
+	}
+	sort.Strings(failed)
+	expected := []string{"file:///foo.iso", missing.URL}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(failed, expected) {
+		t.Fatalf("bad: %#v", failed)
+	}
+}
+
+func TestOrderISOUrls_first(t *testing.T) {
+	urls := []string{"http://a/foo.iso", "http://b/foo.iso"}
+	ordered := orderISOUrls(context.Background(), urls, "first", time.Second)
+	if !reflect.DeepEqual(ordered, urls) {
+		t.Fatalf("bad: %#v", ordered)
+	}
+}
+
+func TestOrderISOUrls_fastest(t *testing.T) {
+	slow := testISOServer(t, 200*time.Millisecond, http.StatusOK)
+	fast := testISOServer(t, 0, http.StatusOK)
+	hung := testISOServer(t, 2*time.Second, http.StatusOK)
+	urls := []string{hung.URL, slow.URL, fast.URL}
+
+	ordered := orderISOUrls(context.Background(), urls, "fastest", time.Second)
+	expected := []string{fast.URL, slow.URL, hung.URL}
+	if !reflect.DeepEqual(ordered, expected) {
+		t.Fatalf("bad: %#v", ordered)
+	}
+}
+
+func TestISOCached(t *testing.T) {
+	dir := t.TempDir()
+	download := commonsteps.StepDownload{
+		Checksum:   "md5:0B0F137F17AC10944716020B018F8126",
+		Extension:  "iso",
+		TargetPath: dir,
+		Url:        []string{"http://a/foo.iso", "http://b/foo.iso"},
+	}
+
+	if isoCached(download) {
+		t.Fatal("should not be cached")
+	}
+
+	_, targetPath, err := download.UseSourceToFindCacheTarget(download.Url[1])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if filepath.Dir(targetPath) != dir {
+		t.Fatalf("bad: %s", targetPath)
+	}
+	if err := os.WriteFile(targetPath, []byte("iso"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !isoCached(download) {
+		t.Fatal("should be cached")
+	}
+
+	// Without a checksum the cache can't be used
+	download.Checksum = "none"
+	if isoCached(download) {
+		t.Fatal("should not be cached")
+	}
+}
@@ -13,10 +13,12 @@ import (
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
-// This step attaches the ISO to the virtual machine.
+// This step attaches the ISO to the virtual machine. The ISO is detached
+// again by StepDetachMedia, or on cleanup if the build fails before that step.
 //
 // Uses:
 //
+//	config *Config
 //	driver Driver
 //	iso_path string
 //	ui packersdk.Ui
@@ -28,6 +30,7 @@ import (
 type stepAttachISO struct{}
 
 func (s *stepAttachISO) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
 	driver := state.Get("driver").(parallelscommon.Driver)
 	isoPath := state.Get("iso_path").(string)
 	ui := state.Get("ui").(packersdk.Ui)
@@ -41,6 +44,9 @@ func (s *stepAttachISO) Run(ctx context.Context, state multistep.StateBag) multi
 		"--image", isoPath,
 		"--enable", "--connect",
 	}
+	if config.ISOInterface != "" {
+		command = append(command, "--iface", config.ISOInterface)
+	}
 	if err := driver.Prlctl(command...); err != nil {
 		err := fmt.Errorf("Error attaching ISO: %s", err)
 		state.Put("error", err)
@@ -14,7 +14,7 @@ import (
 )
 
 // This step creates the virtual disk that will be used as the
-// hard drive for the virtual machine.
+// hard drive for the virtual machine, followed by any additional disks.
 type stepCreateDisk struct{}
 
 func (s *stepCreateDisk) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -40,6 +40,24 @@ func (s *stepCreateDisk) Run(ctx context.Context, state multistep.StateBag) mult
 		return multistep.ActionHalt
 	}
 
+	for i, size := range config.AdditionalDiskSize {
+		command := []string{
+			"set", vmName,
+			"--device-add", "hdd",
+			"--type", config.DiskType,
+			"--size", strconv.FormatUint(uint64(size), 10),
+			"--iface", config.HardDriveInterface,
+		}
+
+		ui.Say(fmt.Sprintf("Creating additional hard drive %d...", i+1))
+		if err := driver.Prlctl(command...); err != nil {
+			err := fmt.Errorf("Error creating additional hard drive %d: %s", i+1, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	return multistep.ActionContinue
 }
 
@@ -46,6 +46,39 @@ func (s *stepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 		"--memsize", strconv.Itoa(config.HWConfig.MemorySize),
 	}
 
+	switch config.Firmware {
+	case "efi":
+		secureBoot := "off"
+		if config.EFISecureBoot {
+			secureBoot = "on"
+		}
+		commands = append(commands, []string{
+			"set", name,
+			"--efi-boot", "on",
+			"--efi-secure-boot", secureBoot,
+		})
+	case "bios":
+		commands = append(commands, []string{
+			"set", name,
+			"--efi-boot", "off",
+		})
+	}
+
+	if config.HWConfig.VideoMemory > 0 {
+		commands = append(commands, []string{
+			"set", name,
+			"--videosize", strconv.Itoa(config.HWConfig.VideoMemory),
+		})
+	}
+
+	if config.HWConfig.NICType != "" {
+		commands = append(commands, []string{
+			"set", name,
+			"--device-set", "net0",
+			"--adapter-type", config.HWConfig.NICType,
+		})
+	}
+
 	if config.HWConfig.Sound {
 		commands = append(commands, []string{
 			"set", name,
@@ -94,9 +127,15 @@ func (s *stepCreateVM) Cleanup(state multistep.StateBag) {
 		return
 	}
 
+	config := state.Get("config").(*Config)
 	driver := state.Get("driver").(parallelscommon.Driver)
 	ui := state.Get("ui").(packersdk.Ui)
 
+	if parallelscommon.KeepRegistered(state, config.KeepRegistered) {
+		ui.Say("Keeping virtual machine registered")
+		return
+	}
+
 	ui.Say("Unregistering virtual machine...")
 	if err := driver.Prlctl("unregister", s.vmName); err != nil {
 		ui.Error(fmt.Sprintf("Error unregistering virtual machine: %s", err))
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type hostCommandTemplate struct {
+	Name      string
+	OutputDir string
+}
+
+// Names of the extension points at which custom prlctl commands can be
+// injected into the build.
+const (
+	extensionPreStart     = "pre_start"
+	extensionPostStart    = "post_start"
+	extensionPreShutdown  = "pre_shutdown"
+	extensionPostShutdown = "post_shutdown"
+	extensionPreExport    = "pre_export"
+	extensionPostExport   = "post_export"
+)
+
+var extensionPoints = []string{
+	extensionPreStart,
+	extensionPostStart,
+	extensionPreShutdown,
+	extensionPostShutdown,
+	extensionPreExport,
+	extensionPostExport,
+}
+
+func isExtensionPoint(point string) bool {
+	for _, p := range extensionPoints {
+		if p == point {
+			return true
+		}
+	}
+	return false
+}
+
+// This step executes the prlctl commands and then the host commands
+// configured for a single extension point. If no commands are configured for
+// the point, this step is skipped.
+//
+// Uses:
+//
+//	config *Config
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+type stepRunExtension struct {
+	Point string
+}
+
+func (s *stepRunExtension) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	commands := config.Extensions[s.Point]
+	hostCommands := config.HostCommands[s.Point]
+	if len(commands) == 0 && len(hostCommands) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Running %s extension...", s.Point))
+	step := &parallelscommon.StepPrlctl{
+		Commands: commands,
+		Ctx:      config.ctx,
+	}
+	if action := step.Run(ctx, state); action != multistep.ActionContinue {
+		return action
+	}
+
+	ictx := config.ctx
+	ictx.Data = &hostCommandTemplate{
+		Name:      state.Get("vmName").(string),
+		OutputDir: config.OutputDir,
+	}
+
+	for _, originalCommand := range hostCommands {
+		command := make([]string, len(originalCommand))
+		for i, arg := range originalCommand {
+			var err error
+			command[i], err = interpolate.Render(arg, &ictx)
+			if err != nil {
+				err = fmt.Errorf("Error preparing host command: %s", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+		}
+
+		ui.Message(fmt.Sprintf("Executing on host: %s", strings.Join(command, " ")))
+		out, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput()
+		log.Printf("Host command output: %s", strings.TrimSpace(string(out)))
+		if err != nil {
+			err = fmt.Errorf("Error executing host command: %s\n%s", err, out)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepRunExtension) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,255 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepRunExtension_impl(t *testing.T) {
+	var _ multistep.Step = new(stepRunExtension)
+}
+
+func TestStepRunExtension(t *testing.T) {
+	for _, point := range extensionPoints {
+		t.Run(point, func(t *testing.T) {
+			state := testState(t)
+			config := state.Get("config").(*Config)
+			config.Extensions = map[string][][]string{
+				point: {
+					{"set", "{{.Name}}", "--description", point},
+				},
+			}
+			step := &stepRunExtension{Point: point}
+
+			driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+			// Test the run
+			if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+				t.Fatalf("bad action: %#v", action)
+			}
+			if _, ok := state.GetOk("error"); ok {
+				t.Fatal("should NOT have error")
+			}
+
+			// Test the driver
+			expected := [][]string{
+				{"set", "foo", "--description", point},
+			}
+			if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+				t.Fatalf("bad: %#v", driver.PrlctlCalls)
+			}
+		})
+	}
+}
+
+func TestStepRunExtension_otherPoint(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.Extensions = map[string][][]string{
+		extensionPreStart: {
+			{"set", "{{.Name}}", "--on-crash", "restart"},
+		},
+	}
+	step := &stepRunExtension{Point: extensionPostStart}
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Commands of other extension points are not executed
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRunExtension_error(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.Extensions = map[string][][]string{
+		extensionPreExport: {
+			{"set", "{{.Name}}", "--on-crash", "restart"},
+			{"set", "{{.Name}}", "--smart-guard", "off"},
+		},
+	}
+	step := &stepRunExtension{Point: extensionPreExport}
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.PrlctlErrs = []error{errors.New("test error")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+
+	// The remaining commands are not executed
+	if len(driver.PrlctlCalls) != 1 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRunExtension_hostCommands(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.OutputDir = t.TempDir()
+	config.Extensions = map[string][][]string{
+		extensionPreExport: {
+			{"set", "{{.Name}}", "--on-crash", "restart"},
+		},
+	}
+	config.HostCommands = map[string][][]string{
+		extensionPreExport: {
+			{"sh", "-c", "echo {{ .Name }} {{ .OutputDir }} > {{ .OutputDir }}/host.txt"},
+		},
+	}
+	step := &stepRunExtension{Point: extensionPreExport}
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Both the prlctl and the host commands are executed
+	if len(driver.PrlctlCalls) != 1 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+
+	out, err := os.ReadFile(filepath.Join(config.OutputDir, "host.txt"))
+	if err != nil {
+		t.Fatalf("host command should have run: %s", err)
+	}
+	if expected := "foo " + config.OutputDir; strings.TrimSpace(string(out)) != expected {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestStepRunExtension_hostCommandsAfterPrlctl(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.OutputDir = t.TempDir()
+	config.Extensions = map[string][][]string{
+		extensionPostStart: {
+			{"set", "{{.Name}}", "--on-crash", "restart"},
+		},
+	}
+	config.HostCommands = map[string][][]string{
+		extensionPostStart: {
+			{"touch", "{{ .OutputDir }}/host.txt"},
+		},
+	}
+	step := &stepRunExtension{Point: extensionPostStart}
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.PrlctlErrs = []error{errors.New("test error")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+
+	// The host commands run after the prlctl commands, so a failing prlctl
+	// command keeps them from running
+	if _, err := os.Stat(filepath.Join(config.OutputDir, "host.txt")); !os.IsNotExist(err) {
+		t.Fatalf("host command should NOT have run: %v", err)
+	}
+}
+
+func TestStepRunExtension_hostCommandError(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.OutputDir = t.TempDir()
+	config.HostCommands = map[string][][]string{
+		extensionPostShutdown: {
+			{"sh", "-c", "echo oops; exit 3"},
+			{"touch", "{{ .OutputDir }}/host.txt"},
+		},
+	}
+	step := &stepRunExtension{Point: extensionPostShutdown}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	err, ok := state.GetOk("error")
+	if !ok {
+		t.Fatal("should have error")
+	}
+	if !strings.Contains(err.(error).Error(), "oops") {
+		t.Fatalf("error should contain the command output: %s", err)
+	}
+
+	// The remaining commands are not executed
+	if _, err := os.Stat(filepath.Join(config.OutputDir, "host.txt")); !os.IsNotExist(err) {
+		t.Fatalf("host command should NOT have run: %v", err)
+	}
+}
+
+func TestStepRunExtension_hostCommandTemplateError(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.HostCommands = map[string][][]string{
+		extensionPreStart: {
+			{"echo", "{{ .Name"},
+		},
+	}
+	step := &stepRunExtension{Point: extensionPreStart}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
+
+func TestStepRunExtension_hostCommandCancel(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.HostCommands = map[string][][]string{
+		extensionPreShutdown: {
+			{"sleep", "5"},
+		},
+	}
+	step := &stepRunExtension{Point: extensionPreShutdown}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// The host command is killed when the build is cancelled
+	start := time.Now()
+	if action := step.Run(ctx, state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("command should have been killed: %s", elapsed)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// The serial port added by stepAttachSerialLog. Newly created VMs have no
+// serial ports, so it is always the first one.
+const serialLogDevice = "serial0"
+
+// This step attaches a serial port to the virtual machine that logs its
+// output to a file in the output directory. The serial port is removed again
+// by stepRemoveSerialLog, or on cleanup if the build fails before that step.
+// If serial_log is disabled, this step is skipped.
+//
+// Uses:
+//
+//	config *Config
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	attachedSerialLog bool
+type stepAttachSerialLog struct{}
+
+func (s *stepAttachSerialLog) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	if !config.SerialLog {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(parallelscommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	path := filepath.Join(config.OutputDir, vmName+"-serial.txt")
+	ui.Say(fmt.Sprintf("Logging the serial port output to %s...", path))
+	command := []string{
+		"set", vmName,
+		"--device-add", "serial",
+		"--output", path,
+	}
+	if err := driver.Prlctl(command...); err != nil {
+		err := fmt.Errorf("Error attaching serial port: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	// Set some state so we know to remove
+	state.Put("attachedSerialLog", true)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepAttachSerialLog) Cleanup(state multistep.StateBag) {
+	if _, ok := state.GetOk("attachedSerialLog"); !ok {
+		return
+	}
+
+	driver := state.Get("driver").(parallelscommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	if err := driver.Prlctl("set", vmName, "--device-del", serialLogDevice); err != nil {
+		ui.Error(fmt.Sprintf("Error removing serial port: %s", err))
+	}
+}
+
+// This step removes the serial port added by stepAttachSerialLog, so that
+// the resulting VM doesn't refer to a log file on the build host. If no
+// serial port was added, this step is skipped.
+//
+// Uses:
+//
+//	attachedSerialLog bool
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+type stepRemoveSerialLog struct{}
+
+func (s *stepRemoveSerialLog) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if _, ok := state.GetOk("attachedSerialLog"); !ok {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(parallelscommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say("Removing the serial port...")
+	if err := driver.Prlctl("set", vmName, "--device-del", serialLogDevice); err != nil {
+		err := fmt.Errorf("Error removing serial port: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	state.Remove("attachedSerialLog")
+
+	return multistep.ActionContinue
+}
+
+func (s *stepRemoveSerialLog) Cleanup(state multistep.StateBag) {}
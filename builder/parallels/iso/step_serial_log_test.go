@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepAttachSerialLog_impl(t *testing.T) {
+	var _ multistep.Step = new(stepAttachSerialLog)
+}
+
+func TestStepAttachSerialLog(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.OutputDir = "output"
+	config.SerialLog = true
+	step := new(stepAttachSerialLog)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Test the driver
+	expected := [][]string{
+		{"set", "foo", "--device-add", "serial", "--output", "output/foo-serial.txt"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+
+	// The serial port is removed on cleanup if it is still attached
+	step.Cleanup(state)
+	expected = append(expected, []string{"set", "foo", "--device-del", "serial0"})
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepAttachSerialLog_skip(t *testing.T) {
+	state := testState(t)
+	step := new(stepAttachSerialLog)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	step.Cleanup(state)
+	if len(driver.PrlctlCalls) != 0 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRemoveSerialLog(t *testing.T) {
+	state := testState(t)
+	state.Put("attachedSerialLog", true)
+	step := new(stepRemoveSerialLog)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Test the driver
+	expected := [][]string{
+		{"set", "foo", "--device-del", "serial0"},
+	}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+
+	// The attach step doesn't remove it again on cleanup
+	new(stepAttachSerialLog).Cleanup(state)
+	if len(driver.PrlctlCalls) != 1 {
+		t.Fatalf("bad: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepRemoveSerialLog_error(t *testing.T) {
+	state := testState(t)
+	state.Put("attachedSerialLog", true)
+	step := new(stepRemoveSerialLog)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.PrlctlErrs = []error{errors.New("test error")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
@@ -6,16 +6,20 @@ package iso
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
-// This step sets the device boot order for the virtual machine.
+// This step sets the device boot order for the virtual machine. If the
+// order doesn't start with a hard disk, it is reset by stepResetBootOrder
+// once the OS has been installed.
 //
 // Uses:
 //
+//	config *Config
 //	driver Driver
 //	ui packersdk.Ui
 //	vmName string
@@ -24,6 +28,7 @@ import (
 type stepSetBootOrder struct{}
 
 func (s *stepSetBootOrder) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
 	driver := state.Get("driver").(parallelscommon.Driver)
 	ui := state.Get("ui").(packersdk.Ui)
 	vmName := state.Get("vmName").(string)
@@ -32,7 +37,7 @@ func (s *stepSetBootOrder) Run(ctx context.Context, state multistep.StateBag) mu
 	ui.Say("Setting the boot order...")
 	command := []string{
 		"set", vmName,
-		"--device-bootorder", "hdd0 cdrom0 net0",
+		"--device-bootorder", strings.Join(config.BootOrder, " "),
 	}
 
 	if err := driver.Prlctl(command...); err != nil {
@@ -46,3 +51,63 @@ func (s *stepSetBootOrder) Run(ctx context.Context, state multistep.StateBag) mu
 }
 
 func (s *stepSetBootOrder) Cleanup(state multistep.StateBag) {}
+
+// This step moves the hard disks to the front of the boot order once the OS
+// has been installed, so that the resulting VM doesn't boot the installer
+// again. If the boot order already starts with a hard disk, this step is
+// skipped.
+//
+// Uses:
+//
+//	config *Config
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+type stepResetBootOrder struct{}
+
+func (s *stepResetBootOrder) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	if len(config.BootOrder) == 0 || strings.HasPrefix(config.BootOrder[0], "hdd") {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(parallelscommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say("Resetting the boot order to boot from the hard disk...")
+	command := []string{
+		"set", vmName,
+		"--device-bootorder", strings.Join(diskFirstBootOrder(config.BootOrder), " "),
+	}
+
+	if err := driver.Prlctl(command...); err != nil {
+		err := fmt.Errorf("Error resetting the boot order: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepResetBootOrder) Cleanup(state multistep.StateBag) {}
+
+// diskFirstBootOrder returns the given boot order with the hard disks moved
+// to the front. If it contains no hard disk, the first one is added.
+func diskFirstBootOrder(order []string) []string {
+	var disks, others []string
+	for _, device := range order {
+		if strings.HasPrefix(device, "hdd") {
+			disks = append(disks, device)
+		} else {
+			others = append(others, device)
+		}
+	}
+	if len(disks) == 0 {
+		disks = []string{"hdd0"}
+	}
+	return append(disks, others...)
+}
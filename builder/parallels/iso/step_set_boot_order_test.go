@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package iso
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+)
+
+func TestStepResetBootOrder_impl(t *testing.T) {
+	var _ multistep.Step = new(stepResetBootOrder)
+}
+
+func TestStepResetBootOrder(t *testing.T) {
+	state := testState(t)
+	step := new(stepResetBootOrder)
+
+	config := state.Get("config").(*Config)
+	config.BootOrder = []string{"cdrom0", "hdd0", "net0"}
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	expected := [][]string{{"set", "foo", "--device-bootorder", "hdd0 cdrom0 net0"}}
+	if !reflect.DeepEqual(driver.PrlctlCalls, expected) {
+		t.Fatalf("bad calls: %#v", driver.PrlctlCalls)
+	}
+}
+
+func TestStepResetBootOrder_skip(t *testing.T) {
+	state := testState(t)
+	step := new(stepResetBootOrder)
+
+	config := state.Get("config").(*Config)
+	config.BootOrder = []string{"hdd0", "cdrom0", "net0"}
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.PrlctlCalls) > 0 {
+		t.Fatal("should not call prlctl")
+	}
+}
+
+func TestDiskFirstBootOrder(t *testing.T) {
+	cases := []struct {
+		order    []string
+		expected []string
+	}{
+		{[]string{"cdrom0", "hdd0", "net0"}, []string{"hdd0", "cdrom0", "net0"}},
+		{[]string{"cdrom0", "hdd1", "net0", "hdd0"}, []string{"hdd1", "hdd0", "cdrom0", "net0"}},
+		{[]string{"cdrom0", "net0"}, []string{"hdd0", "cdrom0", "net0"}},
+	}
+
+	for _, tc := range cases {
+		if actual := diskFirstBootOrder(tc.order); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%#v: expected %#v, got %#v", tc.order, tc.expected, actual)
+		}
+	}
+}
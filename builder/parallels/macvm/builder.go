@@ -38,10 +38,11 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 // a Parallels appliance.
 func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
 	// Create the driver that we'll use to communicate with Parallels
-	driver, err := parallelscommon.NewDriver()
+	driver, err := parallelscommon.NewDriver(ctx, &b.config.DriverConfig)
 	if err != nil {
 		return nil, fmt.Errorf("Failed creating Parallels driver: %s", err)
 	}
+	defer driver.Close()
 
 	// Set up the state.
 	state := new(multistep.BasicStateBag)
@@ -50,29 +51,44 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	state.Put("driver", driver)
 	state.Put("hook", hook)
 	state.Put("ui", ui)
-	state.Put("http_port", 0)
 
 	// Build the steps.
 	steps := []multistep.Step{
+		&parallelscommon.StepCheckVMName{
+			VMName: b.config.VMName,
+		},
 		&parallelscommon.StepOutputDir{
 			Force: b.config.PackerForce,
 			Path:  b.config.OutputDir,
 		},
 		&parallelscommon.StepImport{
-			Name:        b.config.VMName,
-			SourcePath:  b.config.SourcePath,
-			OutputDir:   b.config.OutputDir,
-			ReassignMAC: b.config.ReassignMAC,
+			Name:           b.config.VMName,
+			SourcePath:     b.config.SourcePath,
+			OutputDir:      b.config.OutputDir,
+			ReassignMAC:    b.config.ReassignMAC,
+			KeepRegistered: b.config.KeepRegistered,
 		},
 		&parallelscommon.StepPrlctl{
 			Commands: b.config.Prlctl,
 			Ctx:      b.config.ctx,
 		},
+		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
+		&parallelscommon.StepSharedFolders{
+			SharedFolders: b.config.SharedFolders,
+		},
+		&parallelscommon.StepConfigureHeadless{
+			Headless:       b.config.Headless,
+			VNCBindAddress: b.config.VNCBindAddress,
+			VNCPassword:    b.config.VNCPassword,
+			VNCPortMin:     b.config.VNCPortMin,
+			VNCPortMax:     b.config.VNCPortMax,
+		},
 		&parallelscommon.StepRun{},
 		&parallelscommon.StepTypeBootCommand{
 			BootCommand:    b.config.FlatBootCommand(),
 			BootWait:       b.config.BootWait,
-			HostInterfaces: []string{},
+			HTTPAddress:    b.config.HTTPAddress,
+			HostInterfaces: b.config.HostInterfaces,
 			VMName:         b.config.VMName,
 			Ctx:            b.config.ctx,
 			GroupInterval:  b.config.BootConfig.BootGroupInterval,
@@ -102,12 +118,18 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	}
 
 	steps = append(steps, []multistep.Step{
+		new(parallelscommon.StepRestoreHeadless),
+		new(parallelscommon.StepRemoveSharedFolders),
 		&parallelscommon.StepPrlctl{
 			Commands: b.config.PrlctlPost,
 			Ctx:      b.config.ctx,
 		},
 	}...)
 
+	if b.config.OutputManifest {
+		steps = parallelscommon.TimeSteps(steps, b.config.PackerOnError)
+	}
+
 	// Run the steps.
 	b.runner = commonsteps.NewRunnerWithPauseFn(steps, b.config.PackerConfig, ui, state)
 	b.runner.Run(ctx, state)
@@ -126,7 +148,21 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		return nil, errors.New("Build was halted.")
 	}
 
-	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
+	generatedData := map[string]interface{}{
+		"generated_data": state.Get("generated_data"),
+	}
+	if b.config.OutputManifest {
+		ui.Say("Writing build manifest...")
+		manifest, err := parallelscommon.WriteManifest(driver, b.config.OutputDir, "", state)
+		if err != nil {
+			return nil, err
+		}
+		generatedData["manifest"] = manifest
+	}
+	if b.config.KeepRegistered {
+		return parallelscommon.NewRegisteredArtifact(
+			b.config.OutputDir, b.config.VMName, driver, generatedData)
+	}
 	return parallelscommon.NewArtifact(b.config.OutputDir, generatedData)
 }
 
@@ -13,6 +13,7 @@ import (
 	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
 	"github.com/hashicorp/packer-plugin-sdk/bootcommand"
 	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/shutdowncommand"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
@@ -22,16 +23,27 @@ import (
 // Config is the configuration structure for the builder.
 type Config struct {
 	common.PackerConfig                 `mapstructure:",squash"`
+	commonsteps.HTTPConfig              `mapstructure:",squash"`
+	parallelscommon.DriverConfig        `mapstructure:",squash"`
 	parallelscommon.OutputConfig        `mapstructure:",squash"`
 	parallelscommon.PrlctlConfig        `mapstructure:",squash"`
 	parallelscommon.PrlctlPostConfig    `mapstructure:",squash"`
 	parallelscommon.PrlctlVersionConfig `mapstructure:",squash"`
+	parallelscommon.RunConfig           `mapstructure:",squash"`
+	parallelscommon.SharedFolderConfig  `mapstructure:",squash"`
 	parallelscommon.SSHConfig           `mapstructure:",squash"`
 	shutdowncommand.ShutdownConfig      `mapstructure:",squash"`
 	bootcommand.BootConfig              `mapstructure:",squash"`
 	// The path to a MACVM directory that acts as the source
 	// of this build.
 	SourcePath string `mapstructure:"source_path" required:"true"`
+	// A list of which interfaces on the
+	// host should be searched for a IP address. The first IP address found on one
+	// of these will be used as `{{ .HTTPIP }}` in the boot_command. Defaults to
+	// ["en0", "en1", "en2", "en3", "en4", "en5", "en6", "en7", "en8", "en9",
+	// "ppp0", "ppp1", "ppp2"]. If `http_bind_address` is set to a specific
+	// address, that address is used as `{{ .HTTPIP }}` instead.
+	HostInterfaces []string `mapstructure:"host_interfaces" required:"false"`
 	// This is the name of the MACVM directory for the new
 	// virtual machine, without the file extension. By default this is
 	// "packer-BUILDNAME", where "BUILDNAME" is the name of the build.
@@ -68,14 +80,23 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	// Prepare the errors
 	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, c.HTTPConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.DriverConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.OutputConfig.Prepare(&c.ctx, &c.PackerConfig)...)
 	errs = packersdk.MultiErrorAppend(errs, c.PrlctlConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.PrlctlPostConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.PrlctlVersionConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.RunConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.SharedFolderConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.ShutdownConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.SSHConfig.Prepare(&c.ctx)...)
 
+	if len(c.HostInterfaces) == 0 {
+		c.HostInterfaces = []string{"en0", "en1", "en2", "en3", "en4", "en5", "en6", "en7",
+			"en8", "en9", "ppp0", "ppp1", "ppp2"}
+	}
+
 	if c.SourcePath == "" {
 		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("source_path is required"))
 	} else {
@@ -93,6 +114,15 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 				"will forcibly halt the virtual machine, which may result in data loss.")
 	}
 
+	if c.ValidateEnvironment {
+		check := &parallelscommon.EnvironmentCheck{
+			DriverConfig: &c.DriverConfig,
+			OutputDir:    c.OutputDir,
+			HTTPConfig:   &c.HTTPConfig,
+		}
+		errs = packersdk.MultiErrorAppend(errs, check.Validate()...)
+	}
+
 	// Check for any errors.
 	if errs != nil && len(errs.Errors) > 0 {
 		return warnings, errs
@@ -38,10 +38,11 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 // a Parallels appliance.
 func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
 	// Create the driver that we'll use to communicate with Parallels
-	driver, err := parallelscommon.NewDriver()
+	driver, err := parallelscommon.NewDriver(ctx, &b.config.DriverConfig)
 	if err != nil {
 		return nil, fmt.Errorf("Failed creating Parallels driver: %s", err)
 	}
+	defer driver.Close()
 
 	// Set up the state.
 	state := new(multistep.BasicStateBag)
@@ -50,7 +51,6 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	state.Put("driver", driver)
 	state.Put("hook", hook)
 	state.Put("ui", ui)
-	state.Put("http_port", 0)
 
 	// Build the steps.
 	steps := []multistep.Step{
@@ -58,6 +58,9 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			ParallelsToolsMode:   b.config.ParallelsToolsMode,
 			ParallelsToolsFlavor: b.config.ParallelsToolsFlavor,
 		},
+		&parallelscommon.StepCheckVMName{
+			VMName: b.config.VMName,
+		},
 		&parallelscommon.StepOutputDir{
 			Force: b.config.PackerForce,
 			Path:  b.config.OutputDir,
@@ -68,11 +71,13 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Label:       b.config.FloppyConfig.FloppyLabel,
 		},
 		&parallelscommon.StepImport{
-			Name:        b.config.VMName,
-			SourcePath:  b.config.SourcePath,
-			OutputDir:   b.config.OutputDir,
-			ReassignMAC: b.config.ReassignMAC,
+			Name:           b.config.VMName,
+			SourcePath:     b.config.SourcePath,
+			OutputDir:      b.config.OutputDir,
+			ReassignMAC:    b.config.ReassignMAC,
+			KeepRegistered: b.config.KeepRegistered,
 		},
+		new(stepResizeDisk),
 		&parallelscommon.StepAttachParallelsTools{
 			ParallelsToolsMode: b.config.ParallelsToolsMode,
 		},
@@ -81,11 +86,24 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Commands: b.config.Prlctl,
 			Ctx:      b.config.ctx,
 		},
+		new(stepAuditClonedHardware),
+		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
+		&parallelscommon.StepSharedFolders{
+			SharedFolders: b.config.SharedFolders,
+		},
+		&parallelscommon.StepConfigureHeadless{
+			Headless:       b.config.Headless,
+			VNCBindAddress: b.config.VNCBindAddress,
+			VNCPassword:    b.config.VNCPassword,
+			VNCPortMin:     b.config.VNCPortMin,
+			VNCPortMax:     b.config.VNCPortMax,
+		},
 		&parallelscommon.StepRun{},
 		&parallelscommon.StepTypeBootCommand{
 			BootCommand:    b.config.FlatBootCommand(),
 			BootWait:       b.config.BootWait,
-			HostInterfaces: []string{},
+			HTTPAddress:    b.config.HTTPAddress,
+			HostInterfaces: b.config.HostInterfaces,
 			VMName:         b.config.VMName,
 			Ctx:            b.config.ctx,
 			GroupInterval:  b.config.BootConfig.BootGroupInterval,
@@ -104,7 +122,15 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			ParallelsToolsMode:      b.config.ParallelsToolsMode,
 			Ctx:                     b.config.ctx,
 		},
+		&parallelscommon.StepSetWindowsAutoLogon{
+			Enabled:  b.config.WindowsAutoLogon && b.config.SSHConfig.Comm.Type == "winrm",
+			Username: b.config.SSHConfig.Comm.WinRMUser,
+			Password: b.config.SSHConfig.Comm.WinRMPassword,
+		},
 		new(commonsteps.StepProvision),
+		&parallelscommon.StepRemoveWindowsAutoLogon{
+			Enabled: b.config.WindowsAutoLogon && b.config.SSHConfig.Comm.Type == "winrm",
+		},
 		&parallelscommon.StepShutdown{
 			Command: b.config.ShutdownCommand,
 			Timeout: b.config.ShutdownTimeout,
@@ -112,6 +138,9 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.SSHConfig.Comm,
 		},
+		new(parallelscommon.StepRestoreHeadless),
+		new(parallelscommon.StepRemoveSharedFolders),
+		new(parallelscommon.StepDetachMedia),
 		&parallelscommon.StepPrlctl{
 			Commands: b.config.PrlctlPost,
 			Ctx:      b.config.ctx,
@@ -119,6 +148,13 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&parallelscommon.StepCompactDisk{
 			Skip: b.config.SkipCompaction,
 		},
+		&parallelscommon.StepCreateSnapshot{
+			Name: b.config.SnapshotName,
+		},
+	}
+
+	if b.config.OutputManifest {
+		steps = parallelscommon.TimeSteps(steps, b.config.PackerOnError)
 	}
 
 	// Run the steps.
@@ -139,7 +175,21 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		return nil, errors.New("Build was halted.")
 	}
 
-	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
+	generatedData := map[string]interface{}{
+		"generated_data": state.Get("generated_data"),
+	}
+	if b.config.OutputManifest {
+		ui.Say("Writing build manifest...")
+		manifest, err := parallelscommon.WriteManifest(driver, b.config.OutputDir, "", state)
+		if err != nil {
+			return nil, err
+		}
+		generatedData["manifest"] = manifest
+	}
+	if b.config.KeepRegistered {
+		return parallelscommon.NewRegisteredArtifact(
+			b.config.OutputDir, b.config.VMName, driver, generatedData)
+	}
 	return parallelscommon.NewArtifact(b.config.OutputDir, generatedData)
 }
 
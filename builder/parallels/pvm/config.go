@@ -23,11 +23,15 @@ import (
 // Config is the configuration structure for the builder.
 type Config struct {
 	common.PackerConfig                 `mapstructure:",squash"`
+	commonsteps.HTTPConfig              `mapstructure:",squash"`
 	commonsteps.FloppyConfig            `mapstructure:",squash"`
+	parallelscommon.DriverConfig        `mapstructure:",squash"`
 	parallelscommon.OutputConfig        `mapstructure:",squash"`
 	parallelscommon.PrlctlConfig        `mapstructure:",squash"`
 	parallelscommon.PrlctlPostConfig    `mapstructure:",squash"`
 	parallelscommon.PrlctlVersionConfig `mapstructure:",squash"`
+	parallelscommon.RunConfig           `mapstructure:",squash"`
+	parallelscommon.SharedFolderConfig  `mapstructure:",squash"`
 	parallelscommon.SSHConfig           `mapstructure:",squash"`
 	shutdowncommand.ShutdownConfig      `mapstructure:",squash"`
 	bootcommand.BootConfig              `mapstructure:",squash"`
@@ -35,6 +39,25 @@ type Config struct {
 	// The path to a PVM directory that acts as the source
 	// of this build.
 	SourcePath string `mapstructure:"source_path" required:"true"`
+	// A list of which interfaces on the
+	// host should be searched for a IP address. The first IP address found on one
+	// of these will be used as `{{ .HTTPIP }}` in the boot_command. Defaults to
+	// ["en0", "en1", "en2", "en3", "en4", "en5", "en6", "en7", "en8", "en9",
+	// "ppp0", "ppp1", "ppp2"]. If `http_bind_address` is set to a specific
+	// address, that address is used as `{{ .HTTPIP }}` instead.
+	HostInterfaces []string `mapstructure:"host_interfaces" required:"false"`
+	// The size, in megabytes, to grow the primary disk of the cloned VM to.
+	// The size must not be smaller than the disk of the source VM, shrinking
+	// is not supported. By default the disk keeps the size of the source.
+	DiskSize uint `mapstructure:"disk_size" required:"false"`
+	// Grow the primary disk to `disk_size` after the source VM has been
+	// cloned. Defaults to true.
+	ResizeDiskAfterClone config.Trilean `mapstructure:"resize_disk_after_clone" required:"false"`
+	// The name of a snapshot to take of the VM once it has been shut down, the
+	// installation media have been detached and its disk has been compacted.
+	// The snapshot is stored in the resulting PVM, so the artifact can be
+	// used as a base for linked clones. By default no snapshot is taken.
+	SnapshotName string `mapstructure:"snapshot_name" required:"false"`
 	// Virtual disk image is compacted at the end of
 	// the build process using prl_disk_tool utility (except for the case that
 	// disk_type is set to plain). In certain rare cases, this might corrupt
@@ -49,6 +72,13 @@ type Config struct {
 	// NIC will reused when imported else a new MAC address will be generated
 	// by Parallels. Defaults to "false".
 	ReassignMAC bool `mapstructure:"reassign_mac" required:"false"`
+	// Configure a Windows guest to log on automatically with the
+	// `winrm_username` and `winrm_password` credentials before the
+	// provisioners run. The auto-logon registry values are removed again once
+	// provisioning has finished. This requires Parallels Tools to be installed
+	// in the guest and only takes effect when `communicator` is "winrm".
+	// Defaults to false.
+	WindowsAutoLogon bool `mapstructure:"windows_auto_logon" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -77,16 +107,25 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	// Prepare the errors
 	var errs *packersdk.MultiError
+	errs = packersdk.MultiErrorAppend(errs, c.HTTPConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.FloppyConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.DriverConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.OutputConfig.Prepare(&c.ctx, &c.PackerConfig)...)
 	errs = packersdk.MultiErrorAppend(errs, c.PrlctlConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.PrlctlPostConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.PrlctlVersionConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.RunConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.SharedFolderConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.ShutdownConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.SSHConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.ToolsConfig.Prepare(&c.ctx)...)
 
+	if len(c.HostInterfaces) == 0 {
+		c.HostInterfaces = []string{"en0", "en1", "en2", "en3", "en4", "en5", "en6", "en7",
+			"en8", "en9", "ppp0", "ppp1", "ppp2"}
+	}
+
 	if c.SourcePath == "" {
 		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("source_path is required"))
 	} else {
@@ -98,12 +137,32 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	// Warnings
 	var warnings []string
+	if c.DiskSize != 0 && c.ResizeDiskAfterClone.False() {
+		warnings = append(warnings,
+			"'disk_size' has no effect when 'resize_disk_after_clone' is false.")
+	}
+
+	if c.WindowsAutoLogon && c.SSHConfig.Comm.Type != "winrm" {
+		warnings = append(warnings,
+			"'windows_auto_logon' only takes effect with the winrm communicator.")
+	}
+
 	if c.ShutdownCommand == "" {
 		warnings = append(warnings,
 			"A shutdown_command was not specified. Without a shutdown command, Packer\n"+
 				"will forcibly halt the virtual machine, which may result in data loss.")
 	}
 
+	if c.ValidateEnvironment {
+		check := &parallelscommon.EnvironmentCheck{
+			DriverConfig: &c.DriverConfig,
+			OutputDir:    c.OutputDir,
+			DiskSizeMB:   uint64(c.DiskSize),
+			HTTPConfig:   &c.HTTPConfig,
+		}
+		errs = packersdk.MultiErrorAppend(errs, check.Validate()...)
+	}
+
 	// Check for any errors.
 	if errs != nil && len(errs.Errors) > 0 {
 		return warnings, errs
@@ -4,11 +4,14 @@
 package pvm
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
 
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
@@ -114,3 +117,86 @@ func TestNewConfig_shutdown_timeout(t *testing.T) {
 	warns, errs = (&Config{}).Prepare(cfg)
 	testConfigOk(t, warns, errs)
 }
+
+func TestNewConfig_windowsAutoLogon(t *testing.T) {
+	// Warns when the communicator is not winrm
+	c := testConfig(t)
+	c["windows_auto_logon"] = true
+	warns, errs := (&Config{}).Prepare(c)
+	if len(warns) == 0 {
+		t.Fatal("should have warning")
+	}
+	if errs != nil {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	// Good
+	c = testConfig(t)
+	delete(c, "ssh_username")
+	c["communicator"] = "winrm"
+	c["winrm_username"] = "vagrant"
+	c["winrm_password"] = "vagrant"
+	c["windows_auto_logon"] = true
+	warns, errs = (&Config{}).Prepare(c)
+	testConfigOk(t, warns, errs)
+}
+
+func TestNewConfig_diskSize(t *testing.T) {
+	// Good
+	c := testConfig(t)
+	c["disk_size"] = 60000
+	warns, errs := (&Config{}).Prepare(c)
+	testConfigOk(t, warns, errs)
+
+	// Warns when resizing is disabled
+	c = testConfig(t)
+	c["disk_size"] = 60000
+	c["resize_disk_after_clone"] = false
+	warns, errs = (&Config{}).Prepare(c)
+	if len(warns) == 0 {
+		t.Fatal("should have warning")
+	}
+	if errs != nil {
+		t.Fatalf("should not have error: %s", errs)
+	}
+}
+
+func TestNewConfig_httpConfig(t *testing.T) {
+	c := testConfig(t)
+	c["http_directory"] = "testdata"
+	var config Config
+	warns, errs := config.Prepare(c)
+	testConfigOk(t, warns, errs)
+
+	if config.HTTPPortMin == 0 || config.HTTPPortMax == 0 {
+		t.Fatalf("bad http ports: %d-%d", config.HTTPPortMin, config.HTTPPortMax)
+	}
+	if len(config.HostInterfaces) == 0 {
+		t.Fatal("should have default host_interfaces")
+	}
+}
+
+func TestNewConfig_noBootCommand(t *testing.T) {
+	c := testConfig(t)
+	cfg := &Config{}
+	warns, errs := cfg.Prepare(c)
+	testConfigOk(t, warns, errs)
+
+	// Without a boot command the host IP isn't looked up on the default
+	// host interfaces, so the build doesn't depend on them
+	state := testState(t)
+	state.Put("http_port", 0)
+	step := &parallelscommon.StepTypeBootCommand{
+		BootCommand:    cfg.FlatBootCommand(),
+		HTTPAddress:    cfg.HTTPAddress,
+		HostInterfaces: cfg.HostInterfaces,
+		VMName:         cfg.VMName,
+		Ctx:            cfg.ctx,
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+}
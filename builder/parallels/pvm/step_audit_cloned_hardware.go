@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// This step compares the hardware of the source PVM with the hardware of
+// the cloned virtual machine after the custom prlctl commands have been
+// applied, and reports any differences. It is informational only and never
+// fails the build.
+//
+// Uses:
+//
+//	config *Config
+//	driver Driver
+//	ui packersdk.Ui
+//
+// Produces:
+type stepAuditClonedHardware struct{}
+
+func (s *stepAuditClonedHardware) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	driver := state.Get("driver").(parallelscommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say("Comparing the hardware of the source and the cloned VM...")
+	source, err := driver.GetVMHardware(config.SourcePath)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Could not read the hardware of the source VM: %s", err))
+		return multistep.ActionContinue
+	}
+
+	clonePath := filepath.Join(config.OutputDir, config.VMName+".pvm")
+	clone, err := driver.GetVMHardware(clonePath)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Could not read the hardware of the cloned VM: %s", err))
+		return multistep.ActionContinue
+	}
+
+	names := make([]string, 0, len(source))
+	for name := range source {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []string
+	for _, name := range names {
+		if source[name] != clone[name] {
+			diffs = append(diffs, fmt.Sprintf("%s: %q (source) -> %q (clone)",
+				name, source[name], clone[name]))
+		}
+	}
+
+	if len(diffs) == 0 {
+		ui.Message("The cloned VM has the same hardware as the source VM.")
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Warning: the cloned VM hardware differs from the source VM in %d setting(s):", len(diffs)))
+	for _, diff := range diffs {
+		ui.Message(diff)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepAuditClonedHardware) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pvm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+func TestStepAuditClonedHardware_impl(t *testing.T) {
+	var _ multistep.Step = new(stepAuditClonedHardware)
+}
+
+func TestStepAuditClonedHardware(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.OutputDir = "output"
+	step := new(stepAuditClonedHardware)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.GetVMHardwareResult = map[string]map[string]string{
+		"source.pvm":     {"cpus": "2", "memory": "2048"},
+		"output/foo.pvm": {"cpus": "2", "memory": "2048"},
+	}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Test the driver
+	expected := []string{"source.pvm", "output/foo.pvm"}
+	if !reflect.DeepEqual(driver.GetVMHardwarePaths, expected) {
+		t.Fatalf("bad: %#v", driver.GetVMHardwarePaths)
+	}
+
+	out := state.Get("ui").(*packersdk.BasicUi).Writer.(*bytes.Buffer).String()
+	if !strings.Contains(out, "same hardware") {
+		t.Fatalf("bad: %s", out)
+	}
+}
+
+func TestStepAuditClonedHardware_mismatch(t *testing.T) {
+	state := testState(t)
+	config := state.Get("config").(*Config)
+	config.OutputDir = "output"
+	step := new(stepAuditClonedHardware)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.GetVMHardwareResult = map[string]map[string]string{
+		"source.pvm":     {"cpus": "2", "memory": "2048"},
+		"output/foo.pvm": {"cpus": "4", "memory": "2048"},
+	}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	out := state.Get("ui").(*packersdk.BasicUi).Writer.(*bytes.Buffer).String()
+	if !strings.Contains(out, "differs from the source VM in 1 setting(s)") {
+		t.Fatalf("bad: %s", out)
+	}
+	if !strings.Contains(out, `cpus: "2" (source) -> "4" (clone)`) {
+		t.Fatalf("bad: %s", out)
+	}
+	if strings.Contains(out, "memory:") {
+		t.Fatalf("bad: %s", out)
+	}
+}
+
+func TestStepAuditClonedHardware_error(t *testing.T) {
+	state := testState(t)
+	step := new(stepAuditClonedHardware)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.GetVMHardwareErr = errors.New("test error")
+
+	// The audit is informational and never halts the build
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// The cloned VM isn't read if the source VM can't be read
+	if !reflect.DeepEqual(driver.GetVMHardwarePaths, []string{"source.pvm"}) {
+		t.Fatalf("bad: %#v", driver.GetVMHardwarePaths)
+	}
+
+	out := state.Get("ui").(*packersdk.BasicUi).Writer.(*bytes.Buffer).String()
+	if !strings.Contains(out, "test error") {
+		t.Fatalf("bad: %s", out)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pvm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// This step grows the primary disk of the cloned virtual machine to the
+// configured disk_size. If resizing is disabled or no disk_size is set, this
+// step is skipped.
+//
+// Uses:
+//
+//	config *Config
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+type stepResizeDisk struct{}
+
+func (s *stepResizeDisk) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	driver := state.Get("driver").(parallelscommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	if config.DiskSize == 0 || config.ResizeDiskAfterClone.False() {
+		return multistep.ActionContinue
+	}
+
+	hardware, err := driver.GetVMHardware(filepath.Join(config.OutputDir, config.VMName+".pvm"))
+	if err != nil {
+		err = fmt.Errorf("Error reading the hardware of the cloned VM: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	currentSize, err := strconv.ParseUint(hardware["hdd_size"], 10, 64)
+	if err != nil {
+		err = fmt.Errorf("Error detecting the size of the cloned disk: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if uint64(config.DiskSize) == currentSize {
+		ui.Say(fmt.Sprintf("Warning: the cloned disk is already %d MB, no resize needed.", currentSize))
+		return multistep.ActionContinue
+	}
+
+	if uint64(config.DiskSize) < currentSize {
+		err := fmt.Errorf("disk_size (%d MB) is smaller than the cloned disk (%d MB), "+
+			"shrinking disks is not supported", config.DiskSize, currentSize)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	diskPath, err := driver.DiskPath(vmName)
+	if err != nil {
+		err = fmt.Errorf("Error detecting virtual disk path: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say(fmt.Sprintf("Resizing the disk from %d MB to %d MB...", currentSize, config.DiskSize))
+	if err := driver.ResizeDisk(diskPath, config.DiskSize); err != nil {
+		err = fmt.Errorf("Error resizing disk: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepResizeDisk) Cleanup(state multistep.StateBag) {}
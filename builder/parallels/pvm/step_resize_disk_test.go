@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pvm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	parallelscommon "github.com/Parallels/packer-plugin-parallels/builder/parallels/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+)
+
+func testStepResizeDiskState(t *testing.T, diskSize uint, currentSize string) multistep.StateBag {
+	state := testState(t)
+	state.Get("config").(*Config).DiskSize = diskSize
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.GetVMHardwareResult = map[string]map[string]string{
+		"foo.pvm": {"hdd_size": currentSize},
+	}
+	driver.DiskPathResult = "foo.pvm/harddisk.hdd"
+	return state
+}
+
+func TestStepResizeDisk_impl(t *testing.T) {
+	var _ multistep.Step = new(stepResizeDisk)
+}
+
+func TestStepResizeDisk(t *testing.T) {
+	state := testStepResizeDiskState(t, 131072, "65536")
+	step := new(stepResizeDisk)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.ResizeDiskCalled {
+		t.Fatal("should've called")
+	}
+	if driver.ResizeDiskPath != "foo.pvm/harddisk.hdd" {
+		t.Fatalf("bad: %s", driver.ResizeDiskPath)
+	}
+	if driver.ResizeDiskSize != 131072 {
+		t.Fatalf("bad: %d", driver.ResizeDiskSize)
+	}
+}
+
+func TestStepResizeDisk_skip(t *testing.T) {
+	state := testStepResizeDiskState(t, 0, "65536")
+	step := new(stepResizeDisk)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run without disk_size
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if driver.GetVMHardwareCalled || driver.ResizeDiskCalled {
+		t.Fatal("should NOT have called")
+	}
+
+	// Test the run with resizing disabled
+	cfg := state.Get("config").(*Config)
+	cfg.DiskSize = 131072
+	cfg.ResizeDiskAfterClone = config.TriFalse
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if driver.GetVMHardwareCalled || driver.ResizeDiskCalled {
+		t.Fatal("should NOT have called")
+	}
+}
+
+func TestStepResizeDisk_sameSize(t *testing.T) {
+	state := testStepResizeDiskState(t, 65536, "65536")
+	step := new(stepResizeDisk)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+	if driver.ResizeDiskCalled {
+		t.Fatal("should NOT have called")
+	}
+}
+
+func TestStepResizeDisk_shrink(t *testing.T) {
+	state := testStepResizeDiskState(t, 32768, "65536")
+	step := new(stepResizeDisk)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+	if driver.ResizeDiskCalled {
+		t.Fatal("should NOT have called")
+	}
+}
+
+func TestStepResizeDisk_error(t *testing.T) {
+	state := testStepResizeDiskState(t, 131072, "65536")
+	step := new(stepResizeDisk)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.ResizeDiskErr = errors.New("test error")
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
+
+func TestStepResizeDisk_hardwareError(t *testing.T) {
+	state := testStepResizeDiskState(t, 131072, "65536")
+	step := new(stepResizeDisk)
+
+	driver := state.Get("driver").(*parallelscommon.DriverMock)
+	driver.GetVMHardwareErr = errors.New("test error")
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+	if driver.ResizeDiskCalled {
+		t.Fatal("should NOT have called")
+	}
+}